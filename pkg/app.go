@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"fmt"
 	"os"
 	"text/template"
 
@@ -13,6 +14,7 @@ import (
 // via `envsnap init`.
 type InitOptions struct {
 	Version int
+	Schema  string
 	Terse   bool
 
 	RenderPython bool
@@ -43,6 +45,14 @@ func NewApp() *cli.App {
 			Name:  "debug",
 			Usage: "run envsnap with debug logging",
 		},
+		cli.BoolFlag{
+			Name:  "offline",
+			Usage: "load remote configs only from the local cache",
+		},
+		cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "fetch remote configs again, ignoring the local cache",
+		},
 	}
 	app.Before = func(context *cli.Context) error {
 		if context.Bool("debug") {
@@ -52,6 +62,12 @@ func NewApp() *cli.App {
 			// disable logging.
 			log.SetLevel(log.PanicLevel)
 		}
+
+		if context.Bool("offline") && context.Bool("refresh") {
+			return fmt.Errorf("the --offline and --refresh flags cannot be used together")
+		}
+		remoteCache.Offline = context.Bool("offline")
+		remoteCache.Refresh = context.Bool("refresh")
 		return nil
 	}
 	app.Commands = []cli.Command{
@@ -93,7 +109,10 @@ func NewApp() *cli.App {
 			Usage: "Render the environment as specified by the config",
 			Description: heredoc.Doc(`
 				The rendered environment is output to console by default. The '--file' flag can
-				be used to write the output to file.
+				be used to write the output to file. Files are written atomically, so they are
+				never left partially written, and their parent directories are created if needed.
+				Existing files are not overwritten unless the '--force' flag is given. The '--tee'
+				flag also prints the output which is written to file.
 
 				The output format can be set with the '--output' flag. By default, it will render
 				the results in markdown format. The allowable output formats are:
@@ -101,25 +120,328 @@ func NewApp() *cli.App {
 				  • txt		Plaintext output (.txt)
 				  • yaml	YAML output      (.yaml)
 				  • json	JSON output      (.json)
+				  • html	HTML output      (.html)
+				  • gfm		GitHub markdown  (.md)
+				  • toml	TOML output      (.toml)
+				  • ini		INI output       (.ini)
+				  • env		dotenv output    (.env)
+				  • cyclonedx	CycloneDX SBOM   (.cdx.json)
+				  • spdx	SPDX SBOM        (.spdx.json)
+
+				The '--output' flag can be given more than once, to write several formats from a
+				single render. Each output can name the file it is written to, as FORMAT=FILE
+				(e.g. '-o json=snapshot.json -o md'); outputs without a file are written to the
+				'--file' file, if it is given, or to the console. If no '--output' flag is given,
+				the outputs set by the 'outputs' key in the config are used, if any.
+
+				GitHub markdown output wraps each section in a collapsible block, renders key/value
+				sections as tables, and collapses command output which is longer than the number
+				of lines set by the '--collapse-lines' flag.
+
+				The TOML, INI and dotenv outputs flatten the snapshot into sections of key/value
+				pairs. The dotenv output names each value SECTION_KEY and shell-quotes it, so it
+				can be sourced by a shell.
+
+				The CycloneDX and SPDX outputs export the dependencies in the snapshot (the Go
+				standard library and the Python dependencies) as components with package URLs,
+				e.g. pkg:pypi/requests@2.22.0, and record the rest of the environment as metadata.
+
+				JSON output is compact by default; the '--indent' flag pretty-prints it. The
+				'--canonical' flag sorts all keys, normalizes line endings in exec output to '\n',
+				and leaves out the capture time and duration of the snapshot metadata, so that two
+				renders of the same environment are byte-identical.
+
+				The output can also be rendered with a Go template, given by the '--template' flag
+				or by the 'template' key in the config. The template is executed with the typed
+				snapshot (e.g. {{ .System.OS }}) and has sprig-like helper functions, as well as
+				the 'markdown', 'plaintext' and 'gfm' functions, which render a section in that
+				format (e.g. {{ markdown .Python }}). The template in the config is not used if
+				the '--output' flag is given, or if the config sets 'outputs'.
+
+				If the config defines profiles, the '--profile' flag can be used to render only
+				the sections selected by a profile. Use 'envsnap profiles' to list them.
+
+				Before running exec commands listed by a remote config, envsnap shows the
+				commands and asks for confirmation. Confirmed configs are remembered by their
+				content hash, so they are not asked about again until they change. The '--trust'
+				flag trusts the configs without asking, and the '--no-exec' flag renders
+				everything except the exec section.
+
+				The '--meta' flag adds a snapshot section which records when the snapshot was
+				captured and how long it took, the envsnap version, the path and digest of the
+				config, and the hostname. The '--anonymize-hostname' flag replaces the hostname
+				with an identifier derived from it, keyed with a random secret which is kept
+				alongside the trust store.
 				`,
 			),
 			Flags: []cli.Flag{
-				cli.StringFlag{
+				cli.StringSliceFlag{
 					Name:  "output, o",
-					Value: "md",
-					Usage: "specify the output format",
+					Usage: "specify the output format, and optionally the file to write it to, as FORMAT[=FILE] (repeatable; default: md)",
 				},
 				cli.StringFlag{
 					Name:  "file, f",
 					Usage: "write the output to file",
 				},
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "overwrite output files which already exist",
+				},
+				cli.BoolFlag{
+					Name:  "tee",
+					Usage: "also print output which is written to file",
+				},
+				cli.BoolFlag{
+					Name:  "meta",
+					Usage: "include snapshot metadata in the output",
+				},
+				cli.BoolFlag{
+					Name:  "anonymize-hostname",
+					Usage: "record an identifier derived from the hostname instead of the hostname",
+				},
+				cli.IntFlag{
+					Name:  "collapse-lines",
+					Value: defaultCollapseLines,
+					Usage: "collapse command output longer than this many lines in gfm output (0 to never collapse)",
+				},
+				cli.IntFlag{
+					Name:  "indent",
+					Usage: "indent json output by this many spaces (0 for compact output)",
+				},
+				cli.BoolFlag{
+					Name:  "canonical",
+					Usage: "render json output in a canonical form, so renders of the same environment are identical",
+				},
+				cli.StringFlag{
+					Name:  "template, t",
+					Usage: "render the output with a Go template file instead of an output format",
+				},
 				cli.BoolFlag{
 					Name:  "quiet, q",
 					Usage: "ignore any warnings generated during render",
 				},
+				cli.BoolFlag{
+					Name:  "trust",
+					Usage: "trust remote configs to run exec commands without prompting",
+				},
+				cli.BoolFlag{
+					Name:  "no-exec",
+					Usage: "skip all exec commands",
+				},
+				cli.StringFlag{
+					Name:  "profile, p",
+					Usage: "render only the sections selected by the named profile",
+				},
 			},
 			Action: commandRender,
 		},
+		{
+			Name:  "profiles",
+			Usage: "List the profiles defined in the config",
+			Description: heredoc.Doc(`
+				List the profiles defined in the config, along with the sections each profile
+				selects. The default profile, which is rendered when no profile is specified,
+				is marked with an asterisk.
+				`,
+			),
+			Action: commandProfiles,
+		},
+		{
+			Name:  "check",
+			Usage: "Check the environment against the constraints specified by the config",
+			Description: heredoc.Doc(`
+				Render the environment and evaluate the constraints defined in the 'checks'
+				section of the config, printing whether each check passed or failed.
+
+				Any data point referenced by a check is collected, even if it is not listed
+				in its source section of the config. The supported checks are:
+				  • versions	Version constraints on versioned data points, e.g.
+				        	  python.core.version: ">=3.8,<3.12"
+				  • exec		Regular expressions which command output must match
+				  • environment	Environment variables which must be set
+
+				If any check fails, envsnap exits with a non-zero exit code.
+
+				The '--junit' flag also writes the results as a JUnit XML report, for CI systems
+				which display test results. Each check is a test case, and the message of a failed
+				check is taken from the warnings produced while collecting its data point.
+
+				As with 'render', commands from remote configs are only run once the configs
+				are trusted. With the '--no-exec' flag, exec checks are skipped.
+				`,
+			),
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "quiet, q",
+					Usage: "ignore any warnings generated during render",
+				},
+				cli.BoolFlag{
+					Name:  "trust",
+					Usage: "trust remote configs to run exec commands without prompting",
+				},
+				cli.BoolFlag{
+					Name:  "no-exec",
+					Usage: "skip all exec commands and exec checks",
+				},
+				cli.StringFlag{
+					Name:  "junit",
+					Usage: "write the check results to file as a JUnit XML report",
+				},
+			},
+			Action: commandCheck,
+		},
+		{
+			Name:  "validate",
+			Usage: "Validate the config without rendering it",
+			Description: heredoc.Doc(`
+				Validate the config without rendering the environment, so no commands are
+				executed.
+
+				Every problem found is reported along with its line and column in the config.
+				This includes unknown keys, unsupported option values, duplicate keys and list
+				entries, and values of the wrong type.
+				`,
+			),
+			Action: commandValidate,
+		},
+		{
+			Name:      "migrate",
+			Usage:     "Upgrade the config to the latest config version",
+			ArgsUsage: "[CONFIG]",
+			Description: heredoc.Doc(`
+				Upgrade the config to the latest version of the envsnap configuration scheme.
+				If no config is given, it is discovered in the same way as for the 'render'
+				command.
+
+				By default, the changes are printed as a diff and the config is left unchanged.
+				The '--write' flag can be used to rewrite the config file in place instead.
+				Comments and the order of the config options are preserved, though the config
+				may be reformatted.
+
+				Version 2 of the config adds metadata to config items, such as labels and
+				descriptions, which are displayed in the rendered output. Since every version 1
+				config is a valid version 2 config, only the version of the config is changed.
+				`,
+			),
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "write, w",
+					Usage: "write the migrated config in place instead of printing a diff",
+				},
+			},
+			Action: commandMigrate,
+		},
+		{
+			Name:  "schema",
+			Usage: "Print the JSON Schema for the config",
+			Description: heredoc.Doc(`
+				Print the JSON Schema describing the .envsnap config. The schema is generated
+				from the config definition, so it always matches the running version of envsnap.
+
+				Editors which support JSON Schema for YAML files (e.g. via the YAML language
+				server) can use it to complete and validate the config. Configs generated with
+				'envsnap init' reference the published schema in a header comment.
+				`,
+			),
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "write the schema to file",
+				},
+			},
+			Action: commandSchema,
+		},
+		{
+			Name:      "convert",
+			Usage:     "Convert a saved snapshot into another output format",
+			ArgsUsage: "SNAPSHOT",
+			Description: heredoc.Doc(`
+				Convert a snapshot previously rendered in YAML or JSON format into another
+				output format without collecting the environment again.
+
+				The converted snapshot is output to console by default. The '--file' flag can
+				be used to write the output to file. As with 'render', files are written
+				atomically, existing files are only overwritten with the '--force' flag, and
+				the '--tee' flag also prints the output which is written to file.
+
+				The output format can be set with the '--output' flag. By default, it will render
+				the results in markdown format. The allowable output formats are the same as
+				for the 'render' command, and as with 'render', the flag can be given more than
+				once as FORMAT[=FILE]. The '--template' flag can be used to render the snapshot
+				with a Go template instead.
+				`,
+			),
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "output, o",
+					Usage: "specify the output format, and optionally the file to write it to, as FORMAT[=FILE] (repeatable; default: md)",
+				},
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "write the output to file",
+				},
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "overwrite output files which already exist",
+				},
+				cli.BoolFlag{
+					Name:  "tee",
+					Usage: "also print output which is written to file",
+				},
+				cli.IntFlag{
+					Name:  "collapse-lines",
+					Value: defaultCollapseLines,
+					Usage: "collapse command output longer than this many lines in gfm output (0 to never collapse)",
+				},
+				cli.IntFlag{
+					Name:  "indent",
+					Usage: "indent json output by this many spaces (0 for compact output)",
+				},
+				cli.BoolFlag{
+					Name:  "canonical",
+					Usage: "render json output in a canonical form, so renders of the same environment are identical",
+				},
+				cli.StringFlag{
+					Name:  "template, t",
+					Usage: "render the output with a Go template file instead of an output format",
+				},
+			},
+			Action: commandConvert,
+		},
+		{
+			Name:      "sign",
+			Usage:     "Sign a config so it can be verified when loaded remotely",
+			ArgsUsage: "CONFIG",
+			Description: heredoc.Doc(`
+				Create a detached signature for a config with the secret key given by the '--key'
+				flag. The signature is written next to the config, with a '.sig' extension, and
+				should be published alongside it.
+
+				When loading remote configs, envsnap verifies their signatures if any public keys
+				are trusted. Trusted keys are the '.pub' files in the directory set by the
+				ENVSNAP_KEYS_DIR environment variable, or in 'envsnap/keys' in the user config
+				directory. If any keys are trusted, remote configs without a valid signature by
+				one of them are rejected.
+
+				To create a new key pair, use the '--generate-key' flag with the path to write the
+				secret key to. The public key is written to the same path with a '.pub' extension.
+
+				Keys and signatures use the minisign format, so signatures can also be created
+				with 'minisign -S -l' and verified with 'minisign -V'.
+				`,
+			),
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "key, k",
+					Usage: "the secret key to sign the config with",
+				},
+				cli.StringFlag{
+					Name:  "generate-key",
+					Usage: "generate a new key pair, writing the secret key to the given path",
+				},
+			},
+			Action: commandSign,
+		},
 	}
 
 	return app
@@ -11,5 +11,5 @@ func TestNewApp(t *testing.T) {
 
 	assert.Equal(t, "envsnap", app.Name)
 	assert.Equal(t, Version, app.Version)
-	assert.Len(t, app.Commands, 2)
+	assert.Len(t, app.Commands, 9)
 }
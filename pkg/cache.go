@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The environment variable which may be used to override the directory that
+// remote configs are cached in.
+const cacheDirEnv = "ENVSNAP_CACHE_DIR"
+
+// The time for which a cached remote config is used before it is fetched
+// again. Configs pinned to a commit SHA do not expire.
+const remoteCacheTTL = time.Hour
+
+// commitPattern matches a full commit SHA.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// remoteCache is the CLI-wide cache for remote configs. Its options are set
+// by the global CLI flags.
+var remoteCache = &RemoteCache{TTL: remoteCacheTTL}
+
+// RemoteCache caches fetched remote configs on disk, keyed by their
+// reference, so that they can be loaded without network access.
+//
+// A cached config is used if it is pinned to a commit, or if it was fetched
+// within the TTL. Otherwise, the config is fetched again; if that fails, the
+// stale cached config is used instead and a warning is issued.
+type RemoteCache struct {
+	// Dir is the directory that configs are cached in. If empty, the
+	// ENVSNAP_CACHE_DIR environment variable, or the user cache directory,
+	// is used.
+	Dir string
+
+	// Offline loads configs only from the cache, never from the network.
+	Offline bool
+
+	// Refresh always fetches configs, ignoring any cached configs.
+	Refresh bool
+
+	TTL time.Duration
+}
+
+// cacheEntry is a remote config, as it is stored in the cache.
+type cacheEntry struct {
+	Ref     string    `json:"ref"`
+	Commit  string    `json:"commit,omitempty"`
+	Fetched time.Time `json:"fetched"`
+	Data    string    `json:"data"`
+}
+
+// dir gets the directory that remote configs are cached in.
+func (c *RemoteCache) dir() (string, error) {
+	if c.Dir != "" {
+		return c.Dir, nil
+	}
+	if env := os.Getenv(cacheDirEnv); env != "" {
+		return env, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "envsnap", "remote"), nil
+}
+
+// path gets the path of the cache file for the given reference.
+func (c *RemoteCache) path(ref RemoteRef) (string, error) {
+	dir, err := c.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(ref.String())))), nil
+}
+
+// Fetch gets the referenced remote config from the cache or, if it is not
+// cached or has expired, from its source.
+func (c *RemoteCache) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	// Local files are always read directly.
+	if ref.Kind == remoteFile {
+		return fetchRemote(ref)
+	}
+
+	entry, err := c.load(ref)
+	if err != nil {
+		log.WithError(err).WithField("ref", ref.String()).Debug("failed to read cached config")
+	}
+
+	if c.Offline {
+		if entry == nil {
+			return nil, fmt.Errorf("%v: %s", ErrNotCached, ref)
+		}
+		return entry.config(ref), nil
+	}
+
+	if entry != nil && !c.Refresh && (commitPattern.MatchString(ref.Ref) || time.Since(entry.Fetched) < c.TTL) {
+		log.WithField("ref", ref.String()).Debug("using cached config")
+		return entry.config(ref), nil
+	}
+
+	cfg, err := fetchRemote(ref)
+	if err != nil {
+		if entry == nil {
+			return nil, err
+		}
+		cliWarnings.Add("config", "using cached %s: %v", ref, err)
+		return entry.config(ref), nil
+	}
+
+	if err := c.store(cfg); err != nil {
+		log.WithError(err).WithField("ref", ref.String()).Debug("failed to cache config")
+	}
+	return cfg, nil
+}
+
+// load gets the cached entry for the reference. If there is no cached entry,
+// nil is returned.
+func (c *RemoteCache) load(ref RemoteRef) (*cacheEntry, error) {
+	path, err := c.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// store writes the fetched config to the cache. Configs may be fetched from
+// private sources, so the cache is only readable by the user.
+func (c *RemoteCache) store(cfg *RemoteConfig) error {
+	path, err := c.path(cfg.Ref)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		Ref:     cfg.Ref.String(),
+		Commit:  cfg.Commit,
+		Fetched: time.Now().UTC(),
+		Data:    string(cfg.Data),
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// config converts the cache entry back into a remote config.
+func (e *cacheEntry) config(ref RemoteRef) *RemoteConfig {
+	return &RemoteConfig{
+		Ref:    ref,
+		Commit: e.Commit,
+		Data:   []byte(e.Data),
+	}
+}
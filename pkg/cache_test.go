@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFetcher is a ConfigFetcher which counts the number of fetches made.
+type testFetcher struct {
+	fetches int
+	err     error
+}
+
+func (f *testFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &RemoteConfig{Ref: ref, Commit: testCommit, Data: []byte(testRemoteConfig)}, nil
+}
+
+// setupTestCache creates a RemoteCache in a temporary directory, with the
+// GitHub fetcher replaced by a testFetcher.
+func setupTestCache(t *testing.T) (*RemoteCache, *testFetcher, func()) {
+	dir, err := ioutil.TempDir("", "envsnap-cache")
+	assert.NoError(t, err)
+
+	fetcher := &testFetcher{}
+	orig := configFetchers[remoteGithub]
+	configFetchers[remoteGithub] = fetcher
+
+	return &RemoteCache{Dir: dir, TTL: remoteCacheTTL}, fetcher, func() {
+		configFetchers[remoteGithub] = orig
+		cliWarnings.Clear()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRemoteCache_Fetch(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: "v1"}
+
+	cfg, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+	assert.Equal(t, 1, fetcher.fetches)
+
+	// The second fetch is served from the cache.
+	cfg, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, ref, cfg.Ref)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+	assert.Equal(t, 1, fetcher.fetches)
+}
+
+func TestRemoteCache_Store_Private(t *testing.T) {
+	cache, _, teardown := setupTestCache(t)
+	defer teardown()
+	cache.Dir = filepath.Join(cache.Dir, "remote")
+	cache.Refresh = true
+
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: "v1"}
+	path, err := cache.path(ref)
+	assert.NoError(t, err)
+
+	_, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(cache.Dir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRemoteCache_Fetch_Expired(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	cache.TTL = 0
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: "v1"}
+
+	_, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+	_, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetcher.fetches)
+}
+
+func TestRemoteCache_Fetch_Pinned(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	// Configs pinned to a commit never expire.
+	cache.TTL = 0
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: testCommit}
+
+	_, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+	_, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetcher.fetches)
+}
+
+func TestRemoteCache_Fetch_Refresh(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	cache.Refresh = true
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: testCommit}
+
+	_, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+	_, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetcher.fetches)
+}
+
+func TestRemoteCache_Fetch_Offline(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: "v1"}
+
+	cache.Offline = true
+	cfg, err := cache.Fetch(ref)
+	assert.EqualError(t, err, ErrNotCached.Error()+": github.com/foo/bar@v1")
+	assert.Nil(t, cfg)
+	assert.Equal(t, 0, fetcher.fetches)
+
+	cache.Offline = false
+	_, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+
+	// Expired configs are still used when offline.
+	cache.Offline = true
+	cache.TTL = 0
+	cfg, err = cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, 1, fetcher.fetches)
+}
+
+func TestRemoteCache_Fetch_Fallback(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	ref := RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile, Ref: "v1"}
+
+	_, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+
+	// If fetching an expired config fails, the cached config is used.
+	cache.TTL = 0
+	fetcher.err = errors.New("rate limited")
+	cfg, err := cache.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+	assert.Equal(t, 2, fetcher.fetches)
+	assert.Equal(t, []string{"using cached github.com/foo/bar@v1: rate limited"}, cliWarnings.Warnings["config"])
+}
+
+func TestRemoteCache_Fetch_Error(t *testing.T) {
+	cache, fetcher, teardown := setupTestCache(t)
+	defer teardown()
+
+	fetcher.err = errors.New("rate limited")
+	cfg, err := cache.Fetch(RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: configFile})
+	assert.EqualError(t, err, "rate limited")
+	assert.Nil(t, cfg)
+}
+
+func TestRemoteCache_Dir(t *testing.T) {
+	cache := &RemoteCache{Dir: "/tmp/cache"}
+	dir, err := cache.dir()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/cache", dir)
+
+	os.Setenv(cacheDirEnv, "/tmp/env-cache")
+	defer os.Unsetenv(cacheDirEnv)
+
+	cache = &RemoteCache{}
+	dir, err = cache.dir()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/env-cache", dir)
+}
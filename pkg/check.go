@@ -0,0 +1,289 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// CheckConfig defines the constraints that a rendered environment is expected
+// to satisfy. Constraints are evaluated with `envsnap check`.
+type CheckConfig struct {
+	// Versions maps a versioned data point (e.g. "python.core.version") to
+	// the version constraints it must satisfy (e.g. ">=3.8,<3.12").
+	Versions map[string]string `yaml:"versions,omitempty"`
+
+	// Exec maps a command to a regular expression which its output must match.
+	Exec map[string]string `yaml:"exec,omitempty"`
+
+	// Environment lists environment variables which must be set.
+	Environment []string `yaml:"environment,omitempty"`
+}
+
+// IsEmpty checks whether any constraints are configured.
+func (c CheckConfig) IsEmpty() bool {
+	return len(c.Versions) == 0 && len(c.Exec) == 0 && len(c.Environment) == 0
+}
+
+// CheckResult is the outcome of evaluating a single configured constraint.
+type CheckResult struct {
+	// Source is the data point which was checked, e.g. "go.core.version",
+	// "exec.run", or "environment.variables".
+	Source string
+
+	// Item is the specific item within the source which was checked, e.g.
+	// the command or environment variable name. It is empty for sources
+	// which only hold a single value.
+	Item string
+
+	Constraint string
+	Actual     string
+	Passed     bool
+	Message    string
+}
+
+// Name gets the name which identifies the check.
+func (r CheckResult) Name() string {
+	if r.Item == "" {
+		return r.Source
+	}
+	return fmt.Sprintf("%s[%s]", r.Source, r.Item)
+}
+
+// CheckResults is a collection of evaluated constraints.
+type CheckResults []CheckResult
+
+// Failed gets the number of checks which did not pass.
+func (r CheckResults) Failed() int {
+	var failed int
+	for _, res := range r {
+		if !res.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Print out the check results.
+//
+// Each check is printed with its status on its own line in a tabular view,
+// followed by a summary of the number of passed and failed checks.
+func (r CheckResults) Print(writer io.Writer) {
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+
+	tw := NewTabWriter(writer)
+	for _, res := range r {
+		status := green.Sprint("PASS")
+		if !res.Passed {
+			status = red.Sprint("FAIL")
+		}
+		actual := res.Actual
+		if actual == "" {
+			actual = "-"
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s", status, res.Name(), actual, res.Constraint)
+		if res.Message != "" {
+			line += fmt.Sprintf("\t(%s)", res.Message)
+		}
+		fmt.Fprintln(tw, line)
+	}
+	tw.Flush()
+
+	summary := green
+	if r.Failed() > 0 {
+		summary = red
+	}
+	summary.Fprintf(writer, "\nchecks: %d passed, %d failed\n", len(r)-r.Failed(), r.Failed())
+}
+
+// withCheckSources returns a copy of the config which additionally collects
+// every data point referenced by the configured checks, so that checks do not
+// need to be duplicated in the source sections of the config.
+func (c V1EnvsnapConfig) withCheckSources() V1EnvsnapConfig {
+	cfg := c
+	cfg.Python.Core = append(Items{}, c.Python.Core...)
+	cfg.Python.Deps.Packages = append(Items{}, c.Python.Deps.Packages...)
+	cfg.Golang.Core = append(Items{}, c.Golang.Core...)
+	cfg.System.Core = append(Items{}, c.System.Core...)
+	cfg.Exec.Run = append(Items{}, c.Exec.Run...)
+	cfg.Environment.Variables = append(Items{}, c.Environment.Variables...)
+
+	for key := range c.Checks.Versions {
+		parts := strings.SplitN(key, ".", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		// Unsupported keys are not collected; they are reported as failed
+		// checks when evaluated.
+		if _, err := versionOf(&V1EnvsnapResult{}, key); err != nil {
+			continue
+		}
+		switch parts[0] + "." + parts[1] {
+		case "python.core":
+			cfg.Python.Core = cfg.Python.Core.Append(parts[2])
+		case "python.dependencies":
+			cfg.Python.Deps.Packages = cfg.Python.Deps.Packages.Append(parts[2])
+		case "go.core":
+			cfg.Golang.Core = cfg.Golang.Core.Append(parts[2])
+		case "system.core":
+			cfg.System.Core = cfg.System.Core.Append(parts[2])
+		}
+	}
+	for cmd := range c.Checks.Exec {
+		cfg.Exec.Run = cfg.Exec.Run.Append(cmd)
+	}
+	for _, key := range c.Checks.Environment {
+		cfg.Environment.Variables = cfg.Environment.Variables.Append(key)
+	}
+	return cfg
+}
+
+// Evaluate the configured checks against a rendered result.
+//
+// Checks are evaluated in a stable order: versions, then exec, then
+// environment, each sorted alphabetically.
+func (c CheckConfig) Evaluate(res *V1EnvsnapResult) CheckResults {
+	var results CheckResults
+
+	var keys []string
+	for key := range c.Versions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		results = append(results, checkVersion(res, key, c.Versions[key]))
+	}
+
+	var cmds []string
+	for cmd := range c.Exec {
+		cmds = append(cmds, cmd)
+	}
+	sort.Strings(cmds)
+	for _, cmd := range cmds {
+		results = append(results, checkExec(res, cmd, c.Exec[cmd]))
+	}
+
+	vars := append([]string{}, c.Environment...)
+	sort.Strings(vars)
+	for _, key := range vars {
+		results = append(results, checkEnv(res, key))
+	}
+	return results
+}
+
+// checkVersion checks that a versioned data point satisfies its constraints.
+func checkVersion(res *V1EnvsnapResult, key, constraint string) CheckResult {
+	result := CheckResult{
+		Source:     key,
+		Constraint: constraint,
+	}
+
+	constraints, err := ParseVersionConstraints(constraint)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	actual, err := versionOf(res, key)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Actual = actual
+	if actual == "" {
+		result.Message = "no version collected"
+		return result
+	}
+
+	ok, err := constraints.Check(actual)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Passed = ok
+	return result
+}
+
+// checkExec checks that the output of a command matches a regular expression.
+func checkExec(res *V1EnvsnapResult, cmd, pattern string) CheckResult {
+	result := CheckResult{
+		Source:     "exec.run",
+		Item:       cmd,
+		Constraint: fmt.Sprintf("/%s/", pattern),
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.Message = fmt.Sprintf("invalid pattern: %v", err)
+		return result
+	}
+
+	var output string
+	if exec, ok := res.Exec.(ExecResult); ok {
+		output = exec.Exec[cmd]
+	}
+	result.Actual = strings.TrimSpace(output)
+	if output == "" {
+		result.Message = "no output collected"
+		return result
+	}
+	result.Passed = re.MatchString(output)
+	return result
+}
+
+// checkEnv checks that an environment variable is set.
+func checkEnv(res *V1EnvsnapResult, key string) CheckResult {
+	result := CheckResult{
+		Source:     "environment.variables",
+		Item:       key,
+		Constraint: "set",
+	}
+
+	if env, ok := res.Environment.(EnvResult); ok {
+		result.Actual = env.Env[key]
+	}
+	result.Passed = result.Actual != ""
+	if !result.Passed {
+		result.Message = "variable is not set"
+	}
+	return result
+}
+
+// versionOf looks up the value of a versioned data point in the result.
+func versionOf(res *V1EnvsnapResult, key string) (string, error) {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) == 3 {
+		switch parts[0] + "." + parts[1] {
+		case "python.core":
+			python, _ := res.Python.(PythonResult)
+			switch parts[2] {
+			case "version":
+				return python.Version, nil
+			case "py2":
+				return python.VersionPy2, nil
+			case "py3":
+				return python.VersionPy3, nil
+			}
+		case "python.dependencies":
+			python, _ := res.Python.(PythonResult)
+			return python.Deps[parts[2]], nil
+		case "go.core":
+			golang, _ := res.Golang.(GolangResult)
+			if parts[2] == "version" {
+				return golang.Version, nil
+			}
+		case "system.core":
+			system, _ := res.System.(SystemResult)
+			switch parts[2] {
+			case "kernel_version", "kernel-version":
+				return system.KernelVersion, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported version check: %s", key)
+}
@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConfig_IsEmpty(t *testing.T) {
+	assert.True(t, CheckConfig{}.IsEmpty())
+	assert.False(t, CheckConfig{Environment: []string{"HOME"}}.IsEmpty())
+}
+
+func TestCheckResult_Name(t *testing.T) {
+	assert.Equal(t, "go.core.version", CheckResult{Source: "go.core.version"}.Name())
+	assert.Equal(t, "exec.run[ls]", CheckResult{Source: "exec.run", Item: "ls"}.Name())
+}
+
+func TestCheckResults_Failed(t *testing.T) {
+	results := CheckResults{
+		{Passed: true},
+		{Passed: false},
+		{Passed: false},
+	}
+	assert.Equal(t, 2, results.Failed())
+}
+
+func TestCheckResults_Print(t *testing.T) {
+	results := CheckResults{
+		{Source: "go.core.version", Constraint: ">=1.13", Actual: "go1.13.4", Passed: true},
+		{Source: "environment.variables", Item: "FOO", Constraint: "set", Message: "variable is not set"},
+	}
+
+	out := bytes.Buffer{}
+	results.Print(&out)
+
+	assert.Contains(t, out.String(), "go.core.version")
+	assert.Contains(t, out.String(), "environment.variables[FOO]")
+	assert.Contains(t, out.String(), "variable is not set")
+	assert.Contains(t, out.String(), "checks: 1 passed, 1 failed")
+}
+
+func TestV1EnvsnapConfig_withCheckSources(t *testing.T) {
+	cfg := V1EnvsnapConfig{
+		Python: PythonConfig{
+			Core: []Item{{Value: "version"}},
+		},
+		Checks: CheckConfig{
+			Versions: map[string]string{
+				"python.core.version":          ">=3.8",
+				"python.dependencies.requests": ">=2.0",
+				"go.core.version":              ">=1.13",
+				"system.core.kernel_version":   ">=4.0",
+				"foo.bar.baz":                  ">=1.0",
+			},
+			Exec: map[string]string{
+				"echo hello": "hello",
+			},
+			Environment: []string{"HOME"},
+		},
+	}
+
+	actual := cfg.withCheckSources()
+	assert.Equal(t, []string{"version"}, actual.Python.Core.Values())
+	assert.Equal(t, []string{"requests"}, actual.Python.Deps.Packages.Values())
+	assert.Equal(t, []string{"version"}, actual.Golang.Core.Values())
+	assert.Equal(t, []string{"kernel_version"}, actual.System.Core.Values())
+	assert.Equal(t, []string{"echo hello"}, actual.Exec.Run.Values())
+	assert.Equal(t, []string{"HOME"}, actual.Environment.Variables.Values())
+
+	// The original config should not be modified.
+	assert.Empty(t, cfg.Golang.Core)
+	assert.Empty(t, cfg.Exec.Run)
+}
+
+func TestCheckConfig_Evaluate(t *testing.T) {
+	golang := NewGolangResult()
+	golang.Version = "go1.13.4"
+	python := NewPythonResult()
+	python.Version = "2.7.16"
+	exec := NewExecResult()
+	exec.Exec["docker --version"] = "Docker version 19.03.5, build 633a0ea\n"
+	env := NewEnvResult()
+	env.Env["HOME"] = "/home/test"
+	env.Env["EMPTY"] = ""
+
+	res := NewV1EnvsnapResult()
+	res.Golang = golang
+	res.Python = python
+	res.Exec = exec
+	res.Environment = env
+
+	cfg := CheckConfig{
+		Versions: map[string]string{
+			"go.core.version":     ">=1.13",
+			"python.core.version": ">=3.8,<3.12",
+			"python.core.py3":     ">=3.8",
+			"foo.bar.baz":         ">=1.0",
+			"python.core.py2":     "not-a-constraint",
+		},
+		Exec: map[string]string{
+			"docker --version": `^Docker version 19\.`,
+			"git --version":    "git",
+			"ls":               "(",
+		},
+		Environment: []string{"HOME", "EMPTY"},
+	}
+
+	results := cfg.Evaluate(&res)
+	assert.Len(t, results, 10)
+
+	expected := []struct {
+		name   string
+		passed bool
+	}{
+		{"foo.bar.baz", false},
+		{"go.core.version", true},
+		{"python.core.py2", false},
+		{"python.core.py3", false},
+		{"python.core.version", false},
+		{"exec.run[docker --version]", true},
+		{"exec.run[git --version]", false},
+		{"exec.run[ls]", false},
+		{"environment.variables[EMPTY]", false},
+		{"environment.variables[HOME]", true},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.name, results[i].Name())
+		assert.Equal(t, e.passed, results[i].Passed, e.name)
+	}
+	assert.Equal(t, "unsupported version check: foo.bar.baz", results[0].Message)
+	assert.Equal(t, "no version collected", results[3].Message)
+	assert.Equal(t, "no output collected", results[6].Message)
+}
@@ -2,10 +2,12 @@ package pkg
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"text/template"
+	"time"
 
 	"github.com/urfave/cli"
 )
@@ -29,7 +31,8 @@ func commandInit(c *cli.Context) error {
 	}
 
 	opts := InitOptions{
-		Version: configV1,
+		Version: configV2,
+		Schema:  schemaURL,
 		Terse:   c.Bool("terse"),
 	}
 	for _, lang := range c.StringSlice("lang") {
@@ -57,31 +60,77 @@ func commandInit(c *cli.Context) error {
 
 // commandRender is the function executed for the CLI's "render" command.
 func commandRender(c *cli.Context) error {
-	// If no path is provided, assume current working directory.
+	// If no path is provided, discover the config.
 	path := c.Args().Get(0)
 
 	// Get command flags.
-	flagOutput := c.String("output")
+	flagOutputs, err := parseOutputs(c.StringSlice("output"))
+	if err != nil {
+		return err
+	}
 	flagFile := c.String("file")
+	common.CollapseLines = c.Int("collapse-lines")
+	jsonOptions = JSONOptions{
+		Indent:    c.Int("indent"),
+		Canonical: c.Bool("canonical"),
+	}
+	metaOptions = MetaOptions{
+		Enabled:           c.Bool("meta") || c.Bool("anonymize-hostname"),
+		AnonymizeHostname: c.Bool("anonymize-hostname"),
+	}
 
 	cfg, err := LoadConfig(path)
 	if err != nil {
 		return err
 	}
 
-	res, err := cfg.Render()
+	cfg, err = cfg.WithProfile(c.String("profile"))
 	if err != nil {
 		return err
 	}
 
-	if flagFile != "" {
-		if err := res.Write(flagFile, flagOutput); err != nil {
+	cfg, err = trustExec(c, cfg, false)
+	if err != nil {
+		return err
+	}
+
+	// A template given with the --template flag takes precedence over the
+	// outputs and template set in the config, which are only used if no
+	// output format is given either; the outputs in the config take
+	// precedence over its template. The template and outputs are resolved
+	// before rendering, so that any problem with them is reported before any
+	// commands are run.
+	tmplPath := c.String("template")
+	outputs := flagOutputs
+	if tmplPath == "" && len(outputs) == 0 {
+		if outputs = cfg.ConfigOutputs(); len(outputs) == 0 {
+			tmplPath = cfg.TemplatePath()
+		}
+	}
+	var tmpl string
+	if tmplPath != "" {
+		if tmpl, err = loadTemplate(tmplPath); err != nil {
 			return err
 		}
-	} else {
-		if err := res.Print(flagOutput); err != nil {
+		err = checkOverwrite(c.Bool("force"), flagFile)
+	} else if outputs, err = resolveOutputs(outputs, flagFile); err == nil {
+		err = checkOverwrite(c.Bool("force"), outputFiles(outputs)...)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := cfg.Render()
+	if err != nil {
+		return err
+	}
+
+	if tmplPath != "" {
+		if err := writeTemplate(res, tmpl, flagFile, c.Bool("tee")); err != nil {
 			return err
 		}
+	} else if err := writeOutputs(res, outputs, c.Bool("tee")); err != nil {
+		return err
 	}
 
 	// Check for any warnings and print them out.
@@ -93,3 +142,283 @@ func commandRender(c *cli.Context) error {
 	}
 	return nil
 }
+
+// commandConvert is the function executed for the CLI's "convert" command.
+func commandConvert(c *cli.Context) error {
+	path := c.Args().Get(0)
+	if path == "" {
+		return ErrNoSnapshot
+	}
+
+	// Get command flags.
+	flagOutputs, err := parseOutputs(c.StringSlice("output"))
+	if err != nil {
+		return err
+	}
+	flagFile := c.String("file")
+	common.CollapseLines = c.Int("collapse-lines")
+	jsonOptions = JSONOptions{
+		Indent:    c.Int("indent"),
+		Canonical: c.Bool("canonical"),
+	}
+
+	res, err := LoadResult(path)
+	if err != nil {
+		return err
+	}
+
+	if tmplPath := c.String("template"); tmplPath != "" {
+		tmpl, err := loadTemplate(tmplPath)
+		if err != nil {
+			return err
+		}
+		if err := checkOverwrite(c.Bool("force"), flagFile); err != nil {
+			return err
+		}
+		return writeTemplate(res, tmpl, flagFile, c.Bool("tee"))
+	}
+	outputs, err := resolveOutputs(flagOutputs, flagFile)
+	if err != nil {
+		return err
+	}
+	if err := checkOverwrite(c.Bool("force"), outputFiles(outputs)...); err != nil {
+		return err
+	}
+	return writeOutputs(res, outputs, c.Bool("tee"))
+}
+
+// writeTemplate renders the result with the user template and writes it to
+// the file, if one is given, or to stdout otherwise. If tee is set, output
+// which is written to a file is also written to stdout.
+func writeTemplate(res EnvsnapResult, tmpl, file string, tee bool) error {
+	out, err := res.Template(tmpl)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		if err := writeFileAtomic(file, []byte(out), 0644); err != nil {
+			return err
+		}
+		if !tee {
+			return nil
+		}
+	}
+	_, err = fmt.Print(out)
+	return err
+}
+
+// commandCheck is the function executed for the CLI's "check" command.
+func commandCheck(c *cli.Context) error {
+	// If no path is provided, discover the config.
+	path := c.Args().Get(0)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	cfg, err = trustExec(c, cfg, true)
+	if err != nil {
+		return err
+	}
+
+	results, err := cfg.Check()
+	if err != nil {
+		return err
+	}
+	results.Print(os.Stdout)
+
+	if file := c.String("junit"); file != "" {
+		report, err := results.JUnit(cliWarnings)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(file, report, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Check for any warnings and print them out. Warnings may explain
+	// why a data point could not be collected for a check.
+	if !c.Bool("quiet") && cliWarnings.HasWarnings() {
+		cliWarnings.Print(os.Stderr)
+	}
+
+	if results.Failed() > 0 {
+		return ErrCheckFailed
+	}
+	return nil
+}
+
+// commandValidate is the function executed for the CLI's "validate" command.
+func commandValidate(c *cli.Context) error {
+	// If no path is provided, discover the config.
+	path, err := resolveConfigPath(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	data, _, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	errs, err := ValidateConfig(data)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		errs.Print(os.Stderr, path)
+		return ErrInvalidConfig
+	}
+	fmt.Printf("%s: config is valid\n", path)
+	return nil
+}
+
+// commandMigrate is the function executed for the CLI's "migrate" command.
+func commandMigrate(c *cli.Context) error {
+	// If no path is provided, discover the config.
+	path, err := resolveConfigPath(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	migrated, version, err := MigrateConfig(data)
+	if err != nil {
+		return err
+	}
+	if version == latestConfigVersion {
+		fmt.Printf("%s: config is already at version %d\n", path, version)
+		return nil
+	}
+
+	if !c.Bool("write") {
+		diff, err := diffConfig(path, data, migrated)
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff)
+		return nil
+	}
+
+	if err := ioutil.WriteFile(path, migrated, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s: migrated config from version %d to %d\n", path, version, latestConfigVersion)
+	return nil
+}
+
+// commandSchema is the function executed for the CLI's "schema" command.
+func commandSchema(c *cli.Context) error {
+	schema, err := ConfigSchema()
+	if err != nil {
+		return err
+	}
+
+	if file := c.String("file"); file != "" {
+		return ioutil.WriteFile(file, schema, 0644)
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
+
+// commandProfiles is the function executed for the CLI's "profiles" command.
+func commandProfiles(c *cli.Context) error {
+	// If no path is provided, discover the config.
+	path := c.Args().Get(0)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return cfg.PrintProfiles(os.Stdout)
+}
+
+// trustExec ensures that exec commands from remote configs are only run if
+// the user trusts those configs.
+//
+// With the "--no-exec" flag, no commands are run at all. Otherwise, any
+// untrusted remote config which lists a command that will be run must be
+// trusted, either with the "--trust" flag or by confirming a prompt which
+// lists the commands. Commands of exec checks are only run if checks is set.
+// Trusted configs are remembered in the trust store.
+func trustExec(c *cli.Context, cfg EnvsnapConfig, checks bool) (EnvsnapConfig, error) {
+	if c.Bool("no-exec") {
+		return cfg.WithoutExec(), nil
+	}
+
+	store, err := LoadTrustStore("")
+	if err != nil {
+		return nil, err
+	}
+	untrusted := store.Untrusted(cfg.ExecSources(checks))
+	if len(untrusted) == 0 {
+		return cfg, nil
+	}
+
+	if !c.Bool("trust") {
+		if !isTerminal(os.Stdin) {
+			return nil, ErrUntrustedConfig
+		}
+		ok, err := confirmTrust(os.Stdin, os.Stderr, untrusted)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrUntrustedConfig
+		}
+	}
+	return cfg, store.Trust(untrusted)
+}
+
+// commandSign is the function executed for the CLI's "sign" command.
+func commandSign(c *cli.Context) error {
+	if path := c.String("generate-key"); path != "" {
+		key, err := GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, key.Encode(), 0600); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path+".pub", key.Public().Encode(), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("generated key %s\n  secret key: %s\n  public key: %s.pub\n", key.Public(), path, path)
+		return nil
+	}
+
+	path := c.Args().Get(0)
+	if path == "" {
+		return ErrNoConfig
+	}
+	if c.String("key") == "" {
+		return fmt.Errorf("no secret key specified (use --key)")
+	}
+
+	data, err := ioutil.ReadFile(c.String("key"))
+	if err != nil {
+		return err
+	}
+	key, err := ParseSecretKey(data)
+	if err != nil {
+		return err
+	}
+
+	config, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	comment := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filepath.Base(path))
+	if err := ioutil.WriteFile(path+sigExt, key.Sign(config, comment), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s: signed with key %s\n", path+sigExt, key.Public())
+	return nil
+}
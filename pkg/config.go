@@ -1,22 +1,39 @@
 package pkg
 
 import (
-	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
-	"github.com/google/go-github/github"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
 // The name of the config file that envsnap reads from.
 const configFile = ".envsnap"
 
+// The environment variable which may be used to override the config that
+// envsnap loads when no path is specified.
+const configEnv = "ENVSNAP_CONFIG"
+
+// The names of config files which envsnap will look for, in order of
+// preference.
+var configFiles = []string{
+	configFile,
+	configFile + ".yml",
+	configFile + ".yaml",
+}
+
 var (
 	// Version 1 of the envsnap configuration file scheme.
 	configV1 = 1
+
+	// Version 2 of the envsnap configuration file scheme. It adds metadata
+	// to config items (label, description, hidden, redact).
+	configV2 = 2
 )
 
 // RenderConfig defines an interface for configuration sections for envsnap
@@ -38,82 +55,137 @@ type VersionedConfig struct {
 type EnvsnapConfig interface {
 	All() []RenderConfig
 	Render() (EnvsnapResult, error)
+	Check() (CheckResults, error)
+	WithProfile(name string) (EnvsnapConfig, error)
+	WithoutExec() EnvsnapConfig
+	PrintProfiles(writer io.Writer) error
+	Sources() []ConfigSource
+	ExecSources(checks bool) []ConfigSource
+	TemplatePath() string
+	ConfigOutputs() []Output
 }
 
 // LoadConfig loads the configuration for envsnap to render.
 //
-// If no path is specified, it assumes the configuration is in the current
-// working directory. The path may also be a reference to a GitHub repository
-// containing the configuration, in the form of "github.com/<owner>/<repo>[@<ref>]"
-// where the <ref> may be a branch name, tag, or commit.
+// If no path is specified, the configuration is discovered as described by
+// resolveConfigPath. The path may also be a reference to a config in a remote
+// source, such as "github.com/<owner>/<repo>[@<ref>]" where the <ref> may be a
+// branch name, tag, or commit. See RemoteRef for all supported references.
+//
+// A config may extend other configs, which are merged into it before it is
+// decoded (see loadExtendedConfig).
 func LoadConfig(path string) (EnvsnapConfig, error) {
-	var (
-		err  error
-		data []byte
-	)
-
-	// If no path is specified, assume the current working directory.
-	if path == "" {
-		path, err = filepath.Abs(filepath.Join(".", configFile))
-		if err != nil {
-			return nil, err
-		}
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		return nil, err
 	}
 
-	// If the path starts with "github.com/", assume that it is referencing
-	// a remote GitHub repo. This will cause the config to be loaded from
-	// .envsnap file in that repo, instead of locally.
-	if strings.HasPrefix(path, "github.com/") {
-		parts := strings.Split(path, "@")
-		var ref string
-		url := parts[0]
-		if len(parts) > 1 {
-			ref = parts[1]
-		}
+	data, sources, err := loadExtendedConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.(*V1EnvsnapConfig).sources = sources
+	cfg.(*V1EnvsnapConfig).path = path
+	cfg.(*V1EnvsnapConfig).data = data
+	return cfg, nil
+}
 
-		parts = strings.Split(url, "/")
-		if len(parts) != 3 {
-			return nil, ErrInvalidGithubURL
-		}
-		user := parts[1]
-		repo := parts[2]
-
-		ghc := github.NewClient(nil)
-		content, _, _, err := ghc.Repositories.GetContents(
-			context.Background(),
-			user,
-			repo,
-			configFile,
-			&github.RepositoryContentGetOptions{
-				Ref: ref,
-			},
-		)
-		if err != nil {
-			return nil, err
+// resolveConfigPath resolves the path of the configuration to load.
+//
+// If a path is specified, it is used as-is. Otherwise, the path set by the
+// ENVSNAP_CONFIG environment variable is used. If that is not set either,
+// the config file is searched for starting in the current working directory
+// and moving up through its parent directories.
+func resolveConfigPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	if env := os.Getenv(configEnv); env != "" {
+		log.WithField("path", env).Debugf("using config from %s", configEnv)
+		return env, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	path, err = findConfig(cwd)
+	if err != nil {
+		return "", err
+	}
+	log.WithField("path", path).Debug("using discovered config file")
+	return path, nil
+}
+
+// findConfig searches for a config file in the given directory and each of
+// its parents. The search stops at the root of the repository (a directory
+// containing .git) or at the root of the filesystem.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range configFiles {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
 		}
-		ctnt, err := content.GetContent()
-		if err != nil {
-			return nil, err
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", ErrNoConfig
 		}
-		data = []byte(ctnt)
-	} else {
-		// Load from file
-		// Attempt to load the config from file. First, check that
-		// the specified path even exists.
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return nil, ErrNoConfig
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNoConfig
 		}
+		dir = parent
+	}
+}
+
+// readConfig reads the raw configuration data from the specified path. If the
+// config was fetched from a remote source, its source is returned as well.
+func readConfig(path string) ([]byte, *ConfigSource, error) {
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// The file exists. Read from it and figure out which version
-		// of the configuration it is.
-		contents, err := ioutil.ReadFile(path)
+	// If the path references a remote source, fetch the config from that
+	// source instead of loading it from the local filesystem.
+	if isRemoteConfig(path) {
+		cfg, err := fetchConfig(path)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		data = contents
+		source := cfg.Source()
+		return cfg.Data, &source, nil
 	}
 
-	// ----
+	// Load from file
+	// Attempt to load the config from file. First, check that
+	// the specified path even exists.
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil, ErrNoConfig
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
+
+// decodeConfig determines the version of the raw configuration data and
+// strictly decodes it into the corresponding configuration struct. Unknown
+// and duplicate keys are reported as errors.
+func decodeConfig(data []byte) (EnvsnapConfig, error) {
 	v := &VersionedConfig{}
 	if err := yaml.Unmarshal(data, v); err != nil {
 		return nil, err
@@ -123,11 +195,16 @@ func LoadConfig(path string) (EnvsnapConfig, error) {
 	}
 
 	switch *v.Version {
-	case configV1:
+	case configV1, configV2:
 		cfg := &V1EnvsnapConfig{}
-		if err := yaml.Unmarshal(data, cfg); err != nil {
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
 			return nil, err
 		}
+		if *v.Version == configV1 {
+			if err := cfg.checkV1(); err != nil {
+				return nil, err
+			}
+		}
 		return cfg, nil
 
 	default:
@@ -136,12 +213,74 @@ func LoadConfig(path string) (EnvsnapConfig, error) {
 }
 
 // V1EnvsnapConfig contains all the data for the environment snapshot.
+//
+// Version 2 of the config only adds metadata to config items, so both
+// versions are decoded into a V1EnvsnapConfig.
 type V1EnvsnapConfig struct {
+	Version int               `yaml:"version"`
+	Extends []string          `yaml:"extends,omitempty"`
+	Vars    map[string]string `yaml:"vars,omitempty"`
+
+	// Template is the path of a Go template which snapshots are rendered
+	// with, instead of one of the built-in output formats.
+	Template string `yaml:"template,omitempty"`
+
+	// Outputs are the formats which snapshots are written in, and the files
+	// they are written to, if no output format is given when rendering.
+	Outputs []Output `yaml:"outputs,omitempty"`
+
 	Environment EnvConfig    `yaml:"environment,omitempty"`
 	Exec        ExecConfig   `yaml:"exec,omitempty"`
 	Golang      GolangConfig `yaml:"go,omitempty"`
 	Python      PythonConfig `yaml:"python,omitempty"`
 	System      SystemConfig `yaml:"system,omitempty"`
+
+	Checks CheckConfig `yaml:"checks,omitempty"`
+
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+	DefaultProfile string             `yaml:"default_profile,omitempty"`
+
+	// sources are the remote configs which the config was loaded from.
+	sources []ConfigSource
+
+	// path is the path of the loaded config, and data is the config after
+	// resolving the configs it extends and interpolating variables. They
+	// are recorded in the metadata of rendered snapshots.
+	path string
+	data []byte
+}
+
+// TemplatePath gets the path of the template to render snapshots with, or
+// an empty string if the config does not set one.
+func (c V1EnvsnapConfig) TemplatePath() string {
+	return c.Template
+}
+
+// ConfigOutputs gets the outputs which snapshots are written to, or nil if
+// the config does not set any.
+func (c V1EnvsnapConfig) ConfigOutputs() []Output {
+	return c.Outputs
+}
+
+// checkV1 checks that the config only uses options supported by version 1
+// of the config.
+func (c V1EnvsnapConfig) checkV1() error {
+	for _, section := range []struct {
+		path  string
+		items Items
+	}{
+		{"system.core", c.System.Core},
+		{"environment.variables", c.Environment.Variables},
+		{"exec.run", c.Exec.Run},
+		{"python.core", c.Python.Core},
+		{"python.dependencies.packages", c.Python.Deps.Packages},
+		{"go.core", c.Golang.Core},
+	} {
+		if item, ok := section.items.withMeta(); ok {
+			return fmt.Errorf("%s: %s: %v", section.path, item.Value, ErrItemMetaVersion)
+		}
+	}
+	return nil
 }
 
 // All returns all of the configuration components for the v1 envsnap config.
@@ -158,7 +297,9 @@ func (c V1EnvsnapConfig) All() []RenderConfig {
 // Render each configured source into its corresponding v1 result.
 func (c V1EnvsnapConfig) Render() (EnvsnapResult, error) {
 	var err error
+	start := time.Now()
 	v1 := NewV1EnvsnapResult()
+	v1.Config = c.sources
 
 	v1.System, err = c.System.Render()
 	if err != nil {
@@ -185,5 +326,23 @@ func (c V1EnvsnapConfig) Render() (EnvsnapResult, error) {
 		return nil, err
 	}
 
+	if metaOptions.Enabled {
+		v1.Meta = newSnapshotMeta(start, c.path, c.data, metaOptions)
+	}
 	return &v1, nil
 }
+
+// Check renders the configured sources, along with any additional data points
+// referenced by the configured checks, and evaluates the checks against the
+// rendered result.
+func (c V1EnvsnapConfig) Check() (CheckResults, error) {
+	if c.Checks.IsEmpty() {
+		return nil, ErrNoChecks
+	}
+
+	res, err := c.withCheckSources().Render()
+	if err != nil {
+		return nil, err
+	}
+	return c.Checks.Evaluate(res.(*V1EnvsnapResult)), nil
+}
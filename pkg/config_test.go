@@ -1,6 +1,9 @@
 package pkg
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,7 +24,7 @@ func TestV1EnvsnapConfig_All(t *testing.T) {
 func TestV1EnvsnapConfig_Render_Err(t *testing.T) {
 	cfg := V1EnvsnapConfig{
 		System: SystemConfig{
-			Core: []string{"foobar"},
+			Core: []Item{{Value: "foobar"}},
 		},
 	}
 
@@ -33,7 +36,7 @@ func TestV1EnvsnapConfig_Render_Err(t *testing.T) {
 func TestV1EnvsnapConfig_Render_Err2(t *testing.T) {
 	cfg := V1EnvsnapConfig{
 		Python: PythonConfig{
-			Core: []string{"foobar"},
+			Core: []Item{{Value: "foobar"}},
 		},
 	}
 
@@ -45,7 +48,7 @@ func TestV1EnvsnapConfig_Render_Err2(t *testing.T) {
 func TestV1EnvsnapConfig_Render_Err3(t *testing.T) {
 	cfg := V1EnvsnapConfig{
 		Golang: GolangConfig{
-			Core: []string{"foobar"},
+			Core: []Item{{Value: "foobar"}},
 		},
 	}
 
@@ -68,3 +71,139 @@ func TestV1EnvsnapConfig_Render_Empty(t *testing.T) {
 	assert.True(t, res.Python.IsEmpty())
 	assert.True(t, res.Golang.IsEmpty())
 }
+
+func TestV1EnvsnapConfig_Check(t *testing.T) {
+	cfg := V1EnvsnapConfig{
+		Checks: CheckConfig{
+			Versions: map[string]string{
+				"go.core.version": ">=1.0",
+			},
+			Exec: map[string]string{
+				`echo "testing"`: "testing",
+			},
+		},
+	}
+
+	results, err := cfg.Check()
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 0, results.Failed())
+}
+
+func TestV1EnvsnapConfig_Check_NoChecks(t *testing.T) {
+	cfg := V1EnvsnapConfig{}
+
+	results, err := cfg.Check()
+	assert.Equal(t, ErrNoChecks, err)
+	assert.Nil(t, results)
+}
+
+func TestDecodeConfig(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 1\nsystem:\n  core:\n  - os\n"))
+	assert.NoError(t, err)
+	assert.IsType(t, &V1EnvsnapConfig{}, cfg)
+	assert.Equal(t, []string{"os"}, cfg.(*V1EnvsnapConfig).System.Core.Values())
+}
+
+func TestDecodeConfig_UnknownKey(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 1\nenviroment:\n  variables:\n  - PATH\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field enviroment not found")
+	assert.Nil(t, cfg)
+}
+
+func TestDecodeConfig_NoVersion(t *testing.T) {
+	cfg, err := decodeConfig([]byte("system:\n  core:\n  - os\n"))
+	assert.Equal(t, ErrNoConfigVersion, err)
+	assert.Nil(t, cfg)
+}
+
+func TestDecodeConfig_InvalidVersion(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 99\n"))
+	assert.Equal(t, ErrInvalidConfigVersion, err)
+	assert.Nil(t, cfg)
+}
+
+func TestDecodeConfig_V2(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 2\nexec:\n  run:\n  - value: docker --version\n    label: Docker engine\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Docker engine", cfg.(*V1EnvsnapConfig).Exec.Run[0].Label)
+}
+
+func TestDecodeConfig_Outputs(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 2\noutputs:\n- format: json\n  file: snapshot.json\n- format: md\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "json", File: "snapshot.json"}, {Format: "md"}}, cfg.ConfigOutputs())
+}
+
+func TestDecodeConfig_V1Meta(t *testing.T) {
+	cfg, err := decodeConfig([]byte("version: 1\nexec:\n  run:\n  - value: docker --version\n    label: Docker engine\n"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Equal(t, "exec.run: docker --version: "+ErrItemMetaVersion.Error(), err.Error())
+}
+
+func TestFindConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "a", "b")
+	assert.NoError(t, os.MkdirAll(sub, 0755))
+	assert.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+
+	// No config in the repo.
+	path, err := findConfig(sub)
+	assert.Equal(t, ErrNoConfig, err)
+	assert.Empty(t, path)
+
+	// A config above the repository root is not used.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, ".envsnap"), []byte("version: 1"), 0644))
+	path, err = findConfig(sub)
+	assert.Equal(t, ErrNoConfig, err)
+	assert.Empty(t, path)
+
+	// A config in the repository root is found from a subdirectory.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, ".envsnap.yml"), []byte("version: 1"), 0644))
+	path, err = findConfig(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, ".envsnap.yml"), path)
+
+	// The closest config is preferred.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, "a", ".envsnap.yaml"), []byte("version: 1"), 0644))
+	path, err = findConfig(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "a", ".envsnap.yaml"), path)
+
+	// .envsnap is preferred over the other file names.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, "a", ".envsnap"), []byte("version: 1"), 0644))
+	path, err = findConfig(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "a", ".envsnap"), path)
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	path, err := resolveConfigPath("foo/.envsnap")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo/.envsnap", path)
+}
+
+func TestResolveConfigPath_Env(t *testing.T) {
+	assert.NoError(t, os.Setenv(configEnv, "/tmp/.envsnap"))
+	defer os.Unsetenv(configEnv)
+
+	path, err := resolveConfigPath("")
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/.envsnap", path)
+}
+
+func TestResolveConfigPath_Discover(t *testing.T) {
+	// The repository's own config is found from the pkg directory.
+	path, err := resolveConfigPath("")
+	assert.NoError(t, err)
+
+	expected, err := filepath.Abs(filepath.Join("..", ".envsnap"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, path)
+}
@@ -0,0 +1,365 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ranks for the pre-release phase of a version. A version with no pre-release
+// phase is a final release, which sorts after all pre-releases.
+const (
+	phaseDev = iota
+	phaseAlpha
+	phaseBeta
+	phaseRC
+	phaseFinal
+)
+
+// version is a parsed version string, used to compare versions reported by
+// the environment against configured constraints.
+//
+// Parsing is lenient and aims to understand both semantic versions (e.g.
+// "1.2.3-rc.1") and PEP 440 versions (e.g. "3.8.0rc1", "1.0.post2",
+// "2.0.dev1"), as well as version strings reported by tools, such as "go1.13.4"
+// or "v1.2.0".
+type version struct {
+	raw string
+
+	release []int
+	phase   int
+	label   string
+	pre     int
+	post    int
+	dev     int
+}
+
+// parseVersion parses the given string into a version.
+func parseVersion(s string) (version, error) {
+	v := version{
+		raw:   s,
+		phase: phaseFinal,
+		post:  -1,
+		dev:   -1,
+	}
+
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "go")
+	s = strings.TrimPrefix(s, "v")
+
+	// Local version labels and build metadata do not factor into ordering.
+	if i := strings.IndexByte(s, '+'); i != -1 {
+		s = s[:i]
+	}
+
+	// Parse the release segment, e.g. "1.2.3".
+	i := 0
+	for {
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j == i {
+			break
+		}
+		n, err := strconv.Atoi(s[i:j])
+		if err != nil {
+			return v, fmt.Errorf("invalid version: %s", v.raw)
+		}
+		v.release = append(v.release, n)
+		i = j
+		if i+1 < len(s) && s[i] == '.' && s[i+1] >= '0' && s[i+1] <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+	if len(v.release) == 0 {
+		return v, fmt.Errorf("invalid version: %s", v.raw)
+	}
+
+	// Parse any pre-release, post-release, and development release
+	// segments which follow the release.
+	rest := s[i:]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, ".-_")
+		if rest == "" {
+			break
+		}
+		label := leadingLetters(rest)
+		rest = rest[len(label):]
+		num := leadingDigits(rest)
+		rest = rest[len(num):]
+
+		// Semantic versions separate the pre-release number with a dot,
+		// e.g. "1.0.0-rc.1".
+		if num == "" && strings.HasPrefix(rest, ".") {
+			if n := leadingDigits(rest[1:]); n != "" {
+				num = n
+				rest = rest[1+len(n):]
+			}
+		}
+		n, _ := strconv.Atoi(num)
+
+		switch label {
+		case "dev":
+			v.dev = n
+		case "post", "rev", "r":
+			v.post = n
+		case "a", "alpha":
+			v.phase, v.pre = phaseAlpha, n
+		case "b", "beta":
+			v.phase, v.pre = phaseBeta, n
+		case "c", "rc", "pre", "preview":
+			v.phase, v.pre = phaseRC, n
+		case "":
+			if num == "" {
+				return v, fmt.Errorf("invalid version: %s", v.raw)
+			}
+			// A bare number following the release is an implicit
+			// post-release in PEP 440, e.g. "1.0-1".
+			v.post = n
+		default:
+			// Unknown pre-release labels are ordered alphabetically
+			// amongst themselves, before any known pre-release phase.
+			v.phase, v.label, v.pre = phaseDev, label, n
+		}
+	}
+
+	// A development release of a final version (e.g. "1.0.dev1") sorts
+	// before any of its pre-releases.
+	if v.dev != -1 && v.phase == phaseFinal && v.post == -1 {
+		v.phase = phaseDev
+	}
+	return v, nil
+}
+
+// compare compares the version against another version. It returns -1 if
+// the version is less than the other, 0 if they are equal, and 1 if it is
+// greater than the other.
+func (v version) compare(o version) int {
+	if c := compareRelease(v.release, o.release); c != 0 {
+		return c
+	}
+	if c := compareInt(v.phase, o.phase); c != 0 {
+		return c
+	}
+	if c := strings.Compare(v.label, o.label); c != 0 {
+		return c
+	}
+	if c := compareInt(v.pre, o.pre); c != 0 {
+		return c
+	}
+	if c := compareInt(v.post, o.post); c != 0 {
+		return c
+	}
+
+	// A development release sorts before the release it leads up to.
+	vdev, odev := v.dev, o.dev
+	if vdev == -1 {
+		vdev = int(^uint(0) >> 1)
+	}
+	if odev == -1 {
+		odev = int(^uint(0) >> 1)
+	}
+	return compareInt(vdev, odev)
+}
+
+// versionConstraint is a single comparison which a version must satisfy,
+// e.g. ">=3.8".
+type versionConstraint struct {
+	op      string
+	version version
+
+	// wildcard is set for prefix matching constraints, e.g. "==3.8.*".
+	wildcard bool
+}
+
+// operators supported by version constraints. Longer operators are listed
+// first so they are matched before their prefixes.
+var operators = []string{"===", "==", "!=", "~=", ">=", "<=", ">", "<", "=", "^", "~"}
+
+// VersionConstraints is a set of comma-separated constraints, all of which
+// must be satisfied by a version, e.g. ">=3.8,<3.12".
+type VersionConstraints struct {
+	raw         string
+	constraints []versionConstraint
+}
+
+// ParseVersionConstraints parses a comma-separated set of version constraints.
+//
+// The supported operators are: "==", "!=", ">=", "<=", ">", "<", "~=" (PEP 440
+// compatible release), "^" (same major version), and "~" (same minor version).
+// A version with no operator must match exactly, and the "==" and "!="
+// operators support a trailing wildcard for prefix matching, e.g. "==3.8.*".
+func ParseVersionConstraints(s string) (VersionConstraints, error) {
+	c := VersionConstraints{raw: s}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return c, fmt.Errorf("invalid version constraint: '%s'", s)
+		}
+
+		op := "=="
+		for _, o := range operators {
+			if strings.HasPrefix(part, o) {
+				op = o
+				part = strings.TrimSpace(part[len(o):])
+				break
+			}
+		}
+		switch op {
+		case "=", "===":
+			op = "=="
+		}
+
+		wildcard := false
+		if strings.HasSuffix(part, ".*") {
+			if op != "==" && op != "!=" {
+				return c, fmt.Errorf("invalid version constraint: '%s': wildcards are only supported with == and !=", s)
+			}
+			wildcard = true
+			part = strings.TrimSuffix(part, ".*")
+		}
+
+		v, err := parseVersion(part)
+		if err != nil {
+			return c, fmt.Errorf("invalid version constraint: '%s': %v", s, err)
+		}
+		if op == "~=" && len(v.release) < 2 {
+			return c, fmt.Errorf("invalid version constraint: '%s': ~= requires at least two release segments", s)
+		}
+
+		c.constraints = append(c.constraints, versionConstraint{
+			op:       op,
+			version:  v,
+			wildcard: wildcard,
+		})
+	}
+	return c, nil
+}
+
+// String returns the constraints as they were originally specified.
+func (c VersionConstraints) String() string {
+	return c.raw
+}
+
+// Check whether the given version satisfies all of the constraints.
+func (c VersionConstraints) Check(s string) (bool, error) {
+	v, err := parseVersion(s)
+	if err != nil {
+		return false, err
+	}
+	for _, constraint := range c.constraints {
+		if !constraint.check(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// check whether the given version satisfies the constraint.
+func (c versionConstraint) check(v version) bool {
+	switch c.op {
+	case "==":
+		if c.wildcard {
+			return hasReleasePrefix(v.release, c.version.release)
+		}
+		return v.compare(c.version) == 0
+	case "!=":
+		if c.wildcard {
+			return !hasReleasePrefix(v.release, c.version.release)
+		}
+		return v.compare(c.version) != 0
+	case ">=":
+		return v.compare(c.version) >= 0
+	case "<=":
+		return v.compare(c.version) <= 0
+	case ">":
+		return v.compare(c.version) > 0
+	case "<":
+		return v.compare(c.version) < 0
+	case "~=":
+		// Compatible release: ~=2.2.1 is equivalent to >=2.2.1,==2.2.*
+		prefix := c.version.release[:len(c.version.release)-1]
+		return v.compare(c.version) >= 0 && hasReleasePrefix(v.release, prefix)
+	case "^":
+		// Same major version, or same minor version for 0.x releases.
+		prefix := c.version.release[:1]
+		if c.version.release[0] == 0 && len(c.version.release) > 1 {
+			prefix = c.version.release[:2]
+		}
+		return v.compare(c.version) >= 0 && hasReleasePrefix(v.release, prefix)
+	case "~":
+		// Same minor version, or same major version if no minor is given.
+		prefix := c.version.release
+		if len(prefix) > 2 {
+			prefix = prefix[:2]
+		}
+		return v.compare(c.version) >= 0 && hasReleasePrefix(v.release, prefix)
+	}
+	return false
+}
+
+// compareRelease compares two release segments, treating missing trailing
+// segments as zero (e.g. "3.8" is equal to "3.8.0").
+func compareRelease(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := compareInt(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// hasReleasePrefix checks whether a release segment starts with the given
+// prefix, treating missing trailing segments as zero.
+func hasReleasePrefix(release, prefix []int) bool {
+	for i, p := range prefix {
+		r := 0
+		if i < len(release) {
+			r = release[i]
+		}
+		if r != p {
+			return false
+		}
+	}
+	return true
+}
+
+// compareInt compares two ints, returning -1, 0, or 1.
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// leadingLetters returns the leading run of ASCII letters in a string.
+func leadingLetters(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+		i++
+	}
+	return s[:i]
+}
+
+// leadingDigits returns the leading run of ASCII digits in a string.
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion_Err(t *testing.T) {
+	var tests = []string{
+		"",
+		"abc",
+		"go",
+		"1.0~1",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := parseVersion(tt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	var tests = []struct {
+		a   string
+		b   string
+		cmp int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"go1.13.4", "1.13.4", 0},
+		{"1.0+local.1", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.99.99", 1},
+		{"go1.21rc2", "1.21", -1},
+		{"go1.21rc2", "1.21rc1", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.2", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-rc.1", -1},
+		{"3.8.0a1", "3.8.0b1", -1},
+		{"3.8.0b1", "3.8.0rc1", -1},
+		{"3.8.0rc1", "3.8.0", -1},
+		{"1.0.dev1", "1.0a1", -1},
+		{"1.0a1.dev1", "1.0a1", -1},
+		{"1.0", "1.0.post1", -1},
+		{"1.0.post1.dev1", "1.0.post1", -1},
+		{"1.0.post1", "1.0-1", 0},
+		{"1.0.post1", "1.1", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			a, err := parseVersion(tt.a)
+			assert.NoError(t, err)
+			b, err := parseVersion(tt.b)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.cmp, a.compare(b))
+			assert.Equal(t, -tt.cmp, b.compare(a))
+		})
+	}
+}
+
+func TestParseVersionConstraints_Err(t *testing.T) {
+	var tests = []string{
+		"",
+		">=3.8,",
+		">=abc",
+		">=3.8.*",
+		"~=3",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := ParseVersionConstraints(tt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVersionConstraints_Check(t *testing.T) {
+	var tests = []struct {
+		constraint string
+		version    string
+		ok         bool
+	}{
+		{">=3.8,<3.12", "3.8.0", true},
+		{">=3.8,<3.12", "3.11.4", true},
+		{">=3.8,<3.12", "3.12.0", false},
+		{">=3.8,<3.12", "3.7.9", false},
+		{">=3.8, <3.12", "3.12.0rc1", true},
+		{">=1.21", "go1.21.3", true},
+		{">=1.21", "go1.20.14", false},
+		{">1.0", "1.0", false},
+		{"<=1.0", "1.0", true},
+		{"3.8", "3.8.0", true},
+		{"==3.8", "3.8.1", false},
+		{"=3.8.1", "3.8.1", true},
+		{"!=3.8.1", "3.8.1", false},
+		{"==3.8.*", "3.8.10", true},
+		{"==3.8.*", "3.9.0", false},
+		{"!=3.8.*", "3.9.0", true},
+		{"~=2.2", "2.9", true},
+		{"~=2.2", "3.0", false},
+		{"~=2.2.1", "2.2.5", true},
+		{"~=2.2.1", "2.3.0", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+"_"+tt.version, func(t *testing.T) {
+			c, err := ParseVersionConstraints(tt.constraint)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.constraint, c.String())
+
+			ok, err := c.Check(tt.version)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestVersionConstraints_Check_Err(t *testing.T) {
+	c, err := ParseVersionConstraints(">=1.0")
+	assert.NoError(t, err)
+
+	ok, err := c.Check("not-a-version")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
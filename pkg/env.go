@@ -3,6 +3,7 @@ package pkg
 import (
 	"bytes"
 	"encoding/json"
+	htmltemplate "html/template"
 	"os"
 	"text/template"
 
@@ -13,7 +14,8 @@ import (
 
 // EnvConfig defines the configuration for the "environment" source.
 type EnvConfig struct {
-	Variables []string `yaml:"variables,omitempty"`
+	When      *Condition `yaml:"when,omitempty"`
+	Variables Items      `yaml:"variables,omitempty"`
 }
 
 // Render the EnvConfig into its corresponding EnvResult.
@@ -22,14 +24,24 @@ func (c EnvConfig) Render() (Result, error) {
 	l.Debug("starting render")
 
 	result := NewEnvResult()
+	if !c.When.Applies() {
+		l.Debug("skipping render: condition does not apply")
+		return result, nil
+	}
 
-	for _, key := range c.Variables {
+	for _, item := range c.Variables {
+		if !item.applies(l) {
+			continue
+		}
+		key := item.Value
 		val, _ := os.LookupEnv(key)
+		val = item.redact(val)
 		l.WithFields(log.Fields{
 			"key": key,
 			"val": val,
 		}).Debug("env lookup")
 		result.Env[key] = val
+		result.addMeta(key, item)
 	}
 	return result, nil
 }
@@ -56,6 +68,20 @@ func (r EnvResult) IsEmpty() bool {
 	return len(r.Env) == 0
 }
 
+// withoutHidden gets a copy of the result without the hidden items.
+func (r EnvResult) withoutHidden() EnvResult {
+	env := make(map[string]string, len(r.Env))
+	for k, v := range r.Env {
+		env[k] = v
+	}
+	for _, key := range r.hidden() {
+		delete(env, key)
+	}
+	r.Env = env
+	r.Meta = r.visibleMeta()
+	return r
+}
+
 // Markdown renders the EnvResult to markdown.
 func (r EnvResult) Markdown() ([]byte, error) {
 	log.WithField("src", "env").Debug("rendering to markdown")
@@ -67,8 +93,9 @@ func (r EnvResult) Markdown() ([]byte, error) {
 	md := heredoc.Doc(`
 		**Environment**
 		{{ .CodeFence }}
-		{{ range $k, $v := .Env }}{{ $k }}={{ $v }}
-		{{ end }}{{ .CodeFence }}
+		{{ range $k, $v := .Env }}{{ if $.Shown $k }}{{ with $.Comment $k }}# {{ . }}
+		{{ end }}{{ $k }}={{ $v }}
+		{{ end }}{{ end }}{{ .CodeFence }}
 	`)
 	t := template.Must(template.New("env-md").Parse(md))
 
@@ -90,8 +117,9 @@ func (r EnvResult) Plaintext() ([]byte, error) {
 	plaintext := heredoc.Doc(`
 		Environment
 		-----------
-		{{ range $k, $v := .Env }}{{ $k }}={{ $v }}
-		{{ end -}}
+		{{ range $k, $v := .Env }}{{ if $.Shown $k }}{{ with $.Comment $k }}# {{ . }}
+		{{ end }}{{ $k }}={{ $v }}
+		{{ end }}{{ end -}}
 	`)
 
 	t := template.Must(template.New("env-txt").Parse(plaintext))
@@ -103,6 +131,57 @@ func (r EnvResult) Plaintext() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// GFM renders the EnvResult to GitHub-flavored markdown.
+func (r EnvResult) GFM() ([]byte, error) {
+	log.WithField("src", "env").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>Environment</summary>
+
+		| Variable | Value |
+		| :--- | :--- |{{ range $k, $v := .Env }}{{ if $.Shown $k }}
+		| {{ $.Cell ($.Label $k $k) }} | {{ $.Cell (print $v ($.Describe $k)) }} |{{ end }}{{ end }}
+
+		</details>
+	`)
+	t := template.Must(template.New("env-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the EnvResult to an HTML section.
+func (r EnvResult) HTML() ([]byte, error) {
+	log.WithField("src", "env").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>Environment</summary>
+		<table>{{ range $k, $v := .Env }}{{ if $.Shown $k }}
+		<tr><th>{{ $.Label $k $k }}</th><td><code>{{ $v }}</code>{{ with $.Description $k }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ end }}
+		</table>
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("env-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // YAML renders the EnvResult to YAML.
 func (r EnvResult) YAML() ([]byte, error) {
 	log.WithField("src", "env").Debug("rendering to YAML")
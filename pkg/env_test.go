@@ -1,15 +1,18 @@
 package pkg
 
 import (
+	"os"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestEnvConfig_Render(t *testing.T) {
 	cfg := EnvConfig{
-		Variables: []string{"PATH", "FOO", "BAR"},
+		Variables: []Item{{Value: "PATH"}, {Value: "FOO"}, {Value: "BAR"}},
 	}
 
 	r, err := cfg.Render()
@@ -23,9 +26,33 @@ func TestEnvConfig_Render(t *testing.T) {
 	assert.Contains(t, result.Env, "BAR")
 }
 
+func TestEnvConfig_Render_RedactLog(t *testing.T) {
+	assert.NoError(t, os.Setenv("ENVSNAP_TEST_SECRET", "hunter2"))
+	defer os.Unsetenv("ENVSNAP_TEST_SECRET")
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.DebugLevel)
+
+	cfg := EnvConfig{
+		Variables: []Item{{Value: "ENVSNAP_TEST_SECRET", Redact: true}},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+	assert.Equal(t, redactedValue, r.(EnvResult).Env["ENVSNAP_TEST_SECRET"])
+
+	// Redacted values must not be logged either.
+	assert.NotEmpty(t, hook.AllEntries())
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, "hunter2", entry.Data["val"])
+	}
+}
+
 func TestEnvConfig_Render_None(t *testing.T) {
 	cfg := EnvConfig{
-		Variables: []string{},
+		Variables: []Item{},
 	}
 
 	r, err := cfg.Render()
@@ -81,6 +108,70 @@ func TestEnvResult_Plaintext(t *testing.T) {
 	assert.Equal(t, expected, string(data))
 }
 
+func TestEnvResult_Meta(t *testing.T) {
+	r := NewEnvResult()
+	r.Env["FOO"] = "bar"
+	r.Env["ABC"] = "123"
+	r.Env["TOKEN"] = redactedValue
+	r.addMeta("FOO", Item{Value: "FOO", Label: "Foo", Description: "a test var"})
+	r.addMeta("ABC", Item{Value: "ABC", Hidden: true})
+
+	data, err := r.Markdown()
+	assert.NoError(t, err)
+	assert.Equal(t, "**Environment**\n```\n# Foo (a test var)\nFOO=bar\nTOKEN=[redacted]\n```\n", string(data))
+
+	data, err = r.Plaintext()
+	assert.NoError(t, err)
+	assert.Equal(t, "Environment\n-----------\n# Foo (a test var)\nFOO=bar\nTOKEN=[redacted]\n", string(data))
+
+	// Hidden items are still collected in structured output.
+	data, err = r.JSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"ABC":"123"`)
+}
+
+func TestEnvResult_GFM(t *testing.T) {
+	r := NewEnvResult()
+	r.Env["FOO"] = "a|b"
+	r.Env["ABC"] = "123"
+	r.addMeta("ABC", Item{Value: "ABC", Hidden: true})
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Environment</summary>\n\n| Variable | Value |\n| :--- | :--- |\n| FOO | a\\|b |\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestEnvResult_GFM_Empty(t *testing.T) {
+	r := NewEnvResult()
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestEnvResult_HTML(t *testing.T) {
+	r := NewEnvResult()
+	r.Env["FOO"] = "<bar>"
+	r.Env["ABC"] = "123"
+	r.addMeta("ABC", Item{Value: "ABC", Label: "abc", Description: "letters"})
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+
+	expected := "<details class=\"section\" open>\n<summary>Environment</summary>\n<table>\n<tr><th>abc</th><td><code>123</code> <span class=\"description\">letters</span></td></tr>\n<tr><th>FOO</th><td><code>&lt;bar&gt;</code></td></tr>\n</table>\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestEnvResult_HTML_Empty(t *testing.T) {
+	r := NewEnvResult()
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
 func TestEnvResult_Plaintext_Empty(t *testing.T) {
 	r := NewEnvResult()
 
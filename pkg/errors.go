@@ -4,12 +4,32 @@ import "errors"
 
 // Errors used throughout envsnap.
 var (
-	ErrConfigExists         = errors.New(".envsnap file already exists")
-	ErrNoConfig             = errors.New(".envsnap file not found")
-	ErrUnsupportedLang      = errors.New("unsupported language passed to the --lang flag")
-	ErrIncompleteRender     = errors.New("envsnap failed to render some configured options (run with --debug for more detail)")
-	ErrUnsupportedFormat    = errors.New("unsupported format string provided")
-	ErrNoConfigVersion      = errors.New("no version specified in config")
-	ErrInvalidConfigVersion = errors.New("invalid config version specified")
-	ErrInvalidGithubURL     = errors.New("invalid github url: must be in the format 'github.com/<user>/<repo>'")
+	ErrConfigExists           = errors.New(".envsnap file already exists")
+	ErrNoConfig               = errors.New(".envsnap file not found")
+	ErrUnsupportedLang        = errors.New("unsupported language passed to the --lang flag")
+	ErrIncompleteRender       = errors.New("envsnap failed to render some configured options (run with --debug for more detail)")
+	ErrUnsupportedFormat      = errors.New("unsupported format string provided")
+	ErrInvalidOutput          = errors.New("invalid output")
+	ErrOutputExists           = errors.New("output file already exists")
+	ErrNoConfigVersion        = errors.New("no version specified in config")
+	ErrInvalidConfigVersion   = errors.New("invalid config version specified")
+	ErrItemMetaVersion        = errors.New("item metadata (label, description, hidden, redact) requires config version 2 (run 'envsnap migrate' to upgrade)")
+	ErrInvalidConfig          = errors.New("config failed validation")
+	ErrExtendsCycle           = errors.New("config extends itself")
+	ErrRemoteExtends          = errors.New("remote configs may only extend configs in the same remote source")
+	ErrInterpolation          = errors.New("failed to interpolate config")
+	ErrUnknownProfile         = errors.New("profile not found in config")
+	ErrInvalidRemoteRef       = errors.New("invalid remote config reference")
+	ErrNotCached              = errors.New("remote config not cached (fetch it without --offline first)")
+	ErrInvalidKey             = errors.New("invalid key")
+	ErrInvalidSignature       = errors.New("invalid config signature")
+	ErrNoSignature            = errors.New("config signature not found")
+	ErrUntrustedKey           = errors.New("config signed by untrusted key")
+	ErrUntrustedConfig        = errors.New("remote config is not trusted to run commands (use --trust to trust it, or --no-exec to skip commands)")
+	ErrInvalidGithubURL       = errors.New("invalid github url: must be in the format 'github.com/<user>/<repo>'")
+	ErrNoSnapshot             = errors.New("snapshot file not found")
+	ErrNoSnapshotVersion      = errors.New("no version specified in snapshot")
+	ErrNoChecks               = errors.New("no checks specified in config")
+	ErrCheckFailed            = errors.New("envsnap found one or more failing checks")
+	ErrInvalidSnapshotVersion = errors.New("invalid snapshot version specified")
 )
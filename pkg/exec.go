@@ -3,6 +3,7 @@ package pkg
 import (
 	"bytes"
 	"encoding/json"
+	htmltemplate "html/template"
 	"strings"
 	"text/template"
 
@@ -13,7 +14,8 @@ import (
 
 // ExecConfig defines the configuration for the "exec" source.
 type ExecConfig struct {
-	Run []string `yaml:"run,omitempty"`
+	When *Condition `yaml:"when,omitempty"`
+	Run  Items      `yaml:"run,omitempty"`
 }
 
 // Render the ExecConfig into its corresponding ExecResult.
@@ -22,13 +24,23 @@ func (c ExecConfig) Render() (Result, error) {
 	l.Debug("starting render")
 
 	result := NewExecResult()
+	if !c.When.Applies() {
+		l.Debug("skipping render: condition does not apply")
+		return result, nil
+	}
 
-	for _, cmdStr := range c.Run {
+	for _, item := range c.Run {
+		if !item.applies(l) {
+			continue
+		}
+		cmdStr := item.Value
 		args := strings.Split(cmdStr, " ")
 		l.WithField("cmd", cmdStr).Debug("running command")
 		stdout, stderr, err := runCommand(args[0], args[1:]...)
 		if err != nil {
-			errString := stderr.String()
+			// The error output of a redacted command may hold the secret it
+			// would have output.
+			errString := item.redact(stderr.String())
 			if errString == "" {
 				errString = "<no output>"
 			}
@@ -38,9 +50,11 @@ func (c ExecConfig) Render() (Result, error) {
 				"error while running command: '%s'", cmdStr,
 			)
 			result.Exec[cmdStr] = ""
+			result.addMeta(cmdStr, item)
 			continue
 		}
-		result.Exec[cmdStr] = stdout.String()
+		result.Exec[cmdStr] = item.redact(stdout.String())
+		result.addMeta(cmdStr, item)
 	}
 
 	return result, nil
@@ -68,6 +82,20 @@ func (r ExecResult) IsEmpty() bool {
 	return len(r.Exec) == 0
 }
 
+// withoutHidden gets a copy of the result without the hidden items.
+func (r ExecResult) withoutHidden() ExecResult {
+	exec := make(map[string]string, len(r.Exec))
+	for k, v := range r.Exec {
+		exec[k] = v
+	}
+	for _, key := range r.hidden() {
+		delete(exec, key)
+	}
+	r.Exec = exec
+	r.Meta = r.visibleMeta()
+	return r
+}
+
 // Markdown renders the ExecResult to markdown.
 func (r ExecResult) Markdown() ([]byte, error) {
 	log.WithField("src", "exec").Debug("rendering to markdown")
@@ -78,11 +106,11 @@ func (r ExecResult) Markdown() ([]byte, error) {
 
 	md := heredoc.Doc(`
 		**Exec**{{ if .Exec }}{{ $root := . }}
-		{{ range $key, $val := .Exec }}- {{ $root.CodeQuote }}{{ $key }}{{ $root.CodeQuote }}
+		{{ range $key, $val := .Exec }}{{ if $root.Shown $key }}- {{ with $root.Label $key "" }}_{{ . }}_{{ else }}{{ $root.CodeQuote }}{{ $key }}{{ $root.CodeQuote }}{{ end }}{{ $root.Describe $key }}
 		  {{ $root.CodeFence }}
 		  {{ $val }}
 		  {{ $root.CodeFence }}
-		{{ end }}{{ end -}}
+		{{ end }}{{ end }}{{ end -}}
 	`)
 	t := template.Must(template.New("exec-md").Parse(md))
 
@@ -104,9 +132,10 @@ func (r ExecResult) Plaintext() ([]byte, error) {
 	plaintext := heredoc.Doc(`
 		Exec
 		----{{ if .Exec }}
-		{{ range $key, $val := .Exec }}$ {{ $key }}
+		{{ range $key, $val := .Exec }}{{ if $.Shown $key }}{{ with $.Comment $key }}# {{ . }}
+		{{ end }}$ {{ $key }}
 		  {{ $val }}
-		{{ end }}{{ end -}}
+		{{ end }}{{ end }}{{ end -}}
 	`)
 
 	t := template.Must(template.New("exec-txt").Parse(plaintext))
@@ -118,6 +147,64 @@ func (r ExecResult) Plaintext() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// GFM renders the ExecResult to GitHub-flavored markdown.
+func (r ExecResult) GFM() ([]byte, error) {
+	log.WithField("src", "exec").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>Exec</summary>
+		{{ range $key, $val := .Exec }}{{ if $.Shown $key }}
+		{{ with $.Label $key "" }}**{{ . }}** {{ end }}{{ $.CodeQuote }}{{ $key }}{{ $.CodeQuote }}{{ $.Describe $key }}
+		{{ if $.Collapse $val }}
+		<details><summary>{{ $.Lines $val }} lines</summary>
+		{{ end }}
+		{{ $.CodeFence }}
+		{{ $.Output $val }}
+		{{ $.CodeFence }}
+		{{ if $.Collapse $val }}
+		</details>
+		{{ end }}{{ end }}{{ end }}
+		</details>
+	`)
+	t := template.Must(template.New("exec-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the ExecResult to an HTML section.
+func (r ExecResult) HTML() ([]byte, error) {
+	log.WithField("src", "exec").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>Exec</summary>{{ range $key, $val := .Exec }}{{ if $.Shown $key }}
+		<div class="command">
+		<p>{{ with $.Label $key "" }}<strong>{{ . }}</strong> {{ end }}<code>$ {{ $key }}</code>{{ with $.Description $key }} <span class="description">{{ . }}</span>{{ end }}</p>
+		<div class="code"><button class="copy" type="button">Copy</button><pre>{{ $val }}</pre></div>
+		</div>{{ end }}{{ end }}
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("exec-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // YAML renders the ExecResult to YAML.
 func (r ExecResult) YAML() ([]byte, error) {
 	log.WithField("src", "exec").Debug("rendering to YAML")
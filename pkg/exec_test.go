@@ -3,13 +3,15 @@ package pkg
 import (
 	"testing"
 
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestExecConfig_Render(t *testing.T) {
 	cfg := ExecConfig{
-		Run: []string{
-			`echo "testing"`,
+		Run: []Item{
+			{Value: `echo "testing"`},
 		},
 	}
 
@@ -26,8 +28,8 @@ func TestExecConfig_Render_Err(t *testing.T) {
 	defer cliWarnings.Clear()
 
 	cfg := ExecConfig{
-		Run: []string{
-			`ls xyz`,
+		Run: []Item{
+			{Value: `ls xyz`},
 		},
 	}
 
@@ -43,9 +45,34 @@ func TestExecConfig_Render_Err(t *testing.T) {
 	assert.Len(t, cliWarnings.Warnings["exec.run"], 1)
 }
 
+func TestExecConfig_Render_RedactErrLog(t *testing.T) {
+	defer cliWarnings.Clear()
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.DebugLevel)
+
+	cfg := ExecConfig{
+		Run: []Item{
+			{Value: `ls xyz`, Redact: true},
+		},
+	}
+
+	_, err := cfg.Render()
+	assert.NoError(t, err)
+
+	// The error output of a redacted command must not be logged.
+	var messages []string
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Contains(t, messages, "command error: "+redactedValue)
+}
+
 func TestExecConfig_Render_None(t *testing.T) {
 	cfg := ExecConfig{
-		Run: []string{},
+		Run: []Item{},
 	}
 
 	r, err := cfg.Render()
@@ -99,6 +126,88 @@ func TestExecResult_Plaintext(t *testing.T) {
 	assert.Equal(t, expected, string(data))
 }
 
+func TestExecResult_Meta(t *testing.T) {
+	r := NewExecResult()
+	r.Exec["docker --version"] = "Docker version 19.03.5"
+	r.Exec["uname"] = "Linux"
+	r.addMeta("docker --version", Item{Value: "docker --version", Label: "Docker engine", Description: "daemon"})
+	r.addMeta("uname", Item{Value: "uname", Hidden: true})
+
+	data, err := r.Markdown()
+	assert.NoError(t, err)
+	assert.Equal(t, "**Exec**\n- _Docker engine_ (daemon)\n  ```\n  Docker version 19.03.5\n  ```\n", string(data))
+
+	data, err = r.Plaintext()
+	assert.NoError(t, err)
+	assert.Equal(t, "Exec\n----\n# Docker engine (daemon)\n$ docker --version\n  Docker version 19.03.5\n", string(data))
+}
+
+func TestExecConfig_Render_Redact(t *testing.T) {
+	cfg := ExecConfig{
+		Run: []Item{
+			{Value: "echo secret", Redact: true, Hidden: true},
+		},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+
+	result := r.(ExecResult)
+	assert.Equal(t, redactedValue, result.Exec["echo secret"])
+	assert.False(t, result.Shown("echo secret"))
+}
+
+func TestExecResult_GFM(t *testing.T) {
+	r := NewExecResult()
+	r.Exec["echo hello"] = "hello\n"
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Exec</summary>\n\n`echo hello`\n\n```\nhello\n```\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestExecResult_GFM_Collapse(t *testing.T) {
+	r := NewExecResult()
+	r.CollapseLines = 2
+	r.Exec["seq 3"] = "1\n2\n3\n"
+	r.addMeta("seq 3", Item{Value: "seq 3", Label: "Numbers"})
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Exec</summary>\n\n**Numbers** `seq 3`\n\n<details><summary>3 lines</summary>\n\n```\n1\n2\n3\n```\n\n</details>\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestExecResult_GFM_Empty(t *testing.T) {
+	r := NewExecResult()
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestExecResult_HTML(t *testing.T) {
+	r := NewExecResult()
+	r.Exec["echo <b>"] = "<b>"
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+
+	expected := "<details class=\"section\" open>\n<summary>Exec</summary>\n<div class=\"command\">\n<p><code>$ echo &lt;b&gt;</code></p>\n<div class=\"code\"><button class=\"copy\" type=\"button\">Copy</button><pre>&lt;b&gt;</pre></div>\n</div>\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestExecResult_HTML_Empty(t *testing.T) {
+	r := NewExecResult()
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
 func TestExecResult_Plaintext_Empty(t *testing.T) {
 	r := NewExecResult()
 
@@ -144,3 +253,32 @@ func TestExecResult_YAML_Empty(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, data)
 }
+
+func TestExecConfig_Render_When(t *testing.T) {
+	cfg := ExecConfig{
+		Run: []Item{
+			{Value: "echo foo"},
+			{Value: "echo bar", When: &Condition{OS: stringList{"not-an-os"}}},
+		},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+
+	result := r.(ExecResult)
+	assert.Len(t, result.Exec, 1)
+	assert.Equal(t, "foo\n", result.Exec["echo foo"])
+}
+
+func TestExecConfig_Render_WhenSection(t *testing.T) {
+	cfg := ExecConfig{
+		When: &Condition{Bin: stringList{"jk3rlkdal3r93"}},
+		Run: []Item{
+			{Value: "jk3rlkdal3r93 --version"},
+		},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+}
@@ -0,0 +1,291 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// The key in the config which lists the configs that it extends.
+const extendsKey = "extends"
+
+// loadExtendedConfig reads the config at the given path and resolves the
+// configs that it extends, returning the merged raw configuration data.
+//
+// Each config may extend any number of other configs, given either as a path
+// relative to the extending config or as a reference to a remote config (see
+// resolveExtendsPath). Extended configs are merged in the order they are
+// listed, and the extending config is merged on top of them (see mergeConfig).
+//
+// The sources of all remote configs which were loaded are returned along with
+// the merged data, so they can be recorded in the rendered snapshot.
+func loadExtendedConfig(path string) ([]byte, []ConfigSource, error) {
+	merged, sources, _, err := resolveExtends(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, sources, nil
+}
+
+// resolveExtends loads the raw config at the given path and merges it on top
+// of the configs it extends. The chain holds the configs which have already
+// been visited, in order to detect cycles.
+//
+// Each config is interpolated on its own, before it is merged (see
+// interpolateConfig), and the variables it defines are returned so that the
+// configs which extend it can use them. Variables defined in remote configs
+// can not be used by local configs, so that a remote config can not change
+// the commands run by a local config.
+func resolveExtends(path string, chain []string) (map[interface{}]interface{}, []ConfigSource, map[string]string, error) {
+	id := configID(path)
+	for _, visited := range chain {
+		if visited == id {
+			return nil, nil, nil, fmt.Errorf("%s: %v: %s", chain[len(chain)-1], ErrExtendsCycle, strings.Join(append(chain, id), " -> "))
+		}
+	}
+	chain = append(chain, id)
+
+	// Errors in an extended config are attributed to the file which caused
+	// them.
+	wrap := func(err error) error {
+		if len(chain) > 1 {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+		return err
+	}
+
+	data, source, err := readConfig(path)
+	if err != nil {
+		if len(chain) > 1 {
+			return nil, nil, nil, fmt.Errorf("%s: failed to load extended config %s: %v", chain[len(chain)-2], path, err)
+		}
+		return nil, nil, nil, err
+	}
+
+	// Decode the config on its own first, so that any error in it can be
+	// attributed to the file which caused it.
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return nil, nil, nil, wrap(err)
+	}
+
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %v", id, err)
+	}
+	extends := cfg.(*V1EnvsnapConfig).Extends
+	delete(raw, extendsKey)
+
+	var sources []ConfigSource
+	merged := map[interface{}]interface{}{}
+	inherited := map[string]string{}
+	for _, ext := range extends {
+		extPath, err := resolveExtendsPath(path, ext)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %v", id, err)
+		}
+		log.WithFields(log.Fields{
+			"config":  id,
+			"extends": extPath,
+		}).Debug("loading extended config")
+
+		base, baseSources, baseVars, err := resolveExtends(extPath, chain)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !versionCompatible(raw["version"], base["version"]) {
+			return nil, nil, nil, fmt.Errorf("%s: cannot extend %s: config version %v can not extend a config with version %v", id, extPath, raw["version"], base["version"])
+		}
+		merged = mergeConfig(merged, base)
+		sources = append(sources, baseSources...)
+		if isRemoteConfig(path) || !isRemoteConfig(extPath) {
+			for k, v := range baseVars {
+				inherited[k] = v
+			}
+		}
+	}
+
+	raw, vars, err := interpolateConfig(path, raw, inherited)
+	if err != nil {
+		return nil, nil, nil, wrap(err)
+	}
+
+	// The commands run by a remote config are recorded once it has been
+	// interpolated, so that the commands which are trusted are those which
+	// are run.
+	if source != nil {
+		interpolated, err := yaml.Marshal(raw)
+		if err != nil {
+			return nil, nil, nil, wrap(err)
+		}
+		cfg, err := decodeConfig(interpolated)
+		if err != nil {
+			return nil, nil, nil, wrap(err)
+		}
+		source.exec = cfg.(*V1EnvsnapConfig).execCommands()
+		sources = append([]ConfigSource{*source}, sources...)
+	}
+
+	// Remote configs can not choose the files which snapshots are written
+	// to, so the outputs they set are ignored.
+	if _, ok := raw[outputsKey]; ok && source != nil {
+		cliWarnings.Add("config", "ignoring the outputs set by remote config %s", source.Ref)
+		delete(raw, outputsKey)
+	}
+
+	// The template is resolved relative to the config which sets it, as the
+	// configs are merged before they are used.
+	if tmpl, ok := raw[templateKey].(string); ok && tmpl != "" {
+		if raw[templateKey], err = resolveExtendsPath(path, tmpl); err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %v", id, err)
+		}
+	}
+
+	if len(extends) == 0 {
+		return raw, sources, vars, nil
+	}
+	return mergeConfig(merged, raw), sources, vars, nil
+}
+
+// versionCompatible checks whether a config with the given version can
+// extend a config with the base version. Since later config versions only
+// add to earlier ones, a config may extend configs of the same or an older
+// version, but not configs of a newer version.
+func versionCompatible(version, base interface{}) bool {
+	v, ok := version.(int)
+	if !ok {
+		return version == base
+	}
+	b, ok := base.(int)
+	if !ok {
+		return false
+	}
+	return b <= v
+}
+
+// resolveExtendsPath resolves the path of an extended config. For a local
+// extending config, remote configs are used as-is, and local paths are
+// resolved relative to the directory of the extending config.
+//
+// A remote extending config may only extend configs in the same remote
+// source (see RemoteRef.sameSource), given either as a path within it or as
+// a reference to it, so that it can not reach local files or other hosts.
+func resolveExtendsPath(from, ext string) (string, error) {
+	if !isRemoteConfig(from) {
+		if isRemoteConfig(ext) || filepath.IsAbs(ext) {
+			return ext, nil
+		}
+		return filepath.Join(filepath.Dir(from), ext), nil
+	}
+
+	ref, err := ParseRemoteRef(from)
+	if err != nil {
+		return "", err
+	}
+	resolved := ref.Join(ext)
+	if isRemoteConfig(ext) {
+		if resolved, err = ParseRemoteRef(ext); err != nil {
+			return "", err
+		}
+		if resolved.Kind == remoteGit || resolved.Kind == remoteFile {
+			return "", fmt.Errorf("%v: %s", ErrRemoteExtends, ext)
+		}
+	}
+	if !ref.sameSource(resolved) {
+		return "", fmt.Errorf("%v: %s", ErrRemoteExtends, ext)
+	}
+	return resolved.String(), nil
+}
+
+// configRoot gets the root directory of the repository that a config belongs
+// to. For remote configs, this is the repository of the working directory.
+func configRoot(path string) (string, error) {
+	if isRemoteConfig(path) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		return repoRoot(cwd), nil
+	}
+	return repoRoot(filepath.Dir(path)), nil
+}
+
+// configID gets an identifier for a config path which is used to detect
+// cycles and to name configs in errors.
+func configID(path string) string {
+	if isRemoteConfig(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// mergeConfig deep-merges the override config on top of the base config,
+// returning a new config. Neither of the given configs are modified.
+//
+// Mappings are merged key by key. Lists are appended to one another, with
+// items from the override list that already exist in the base list skipped,
+// except for the outputs, which are replaced. All other values in the
+// override replace those in the base.
+func mergeConfig(base, override map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		existing, ok := merged[k]
+		if !ok {
+			merged[k] = v
+			continue
+		}
+
+		switch ov := v.(type) {
+		case map[interface{}]interface{}:
+			if bv, ok := existing.(map[interface{}]interface{}); ok {
+				merged[k] = mergeConfig(bv, ov)
+				continue
+			}
+		case []interface{}:
+			// Outputs of the base and the override would conflict when
+			// they write to the same file, or both to stdout.
+			if k == outputsKey {
+				break
+			}
+			if bv, ok := existing.([]interface{}); ok {
+				list := append([]interface{}{}, bv...)
+				for _, item := range ov {
+					if !containsItem(list, item) {
+						list = append(list, item)
+					}
+				}
+				merged[k] = list
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// containsItem checks whether the list contains the given item.
+func containsItem(list []interface{}, item interface{}) bool {
+	for _, i := range list {
+		if reflect.DeepEqual(i, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,365 @@
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigs writes the given configs, keyed by file name, to a new
+// temporary directory.
+func writeConfigs(t *testing.T, configs map[string]string) string {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+
+	for name, data := range configs {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(heredoc.Doc(data)), 0644))
+	}
+	return dir
+}
+
+func TestLoadConfig_Extends(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base/common.yml": `
+			version: 1
+			system:
+			  core:
+			  - os
+			  - arch
+			environment:
+			  variables:
+			  - PATH
+		`,
+		"base/python.yml": `
+			version: 1
+			extends:
+			- common.yml
+			python:
+			  core:
+			  - version
+		`,
+		".envsnap": `
+			version: 1
+			extends:
+			- base/python.yml
+			system:
+			  core:
+			  - arch
+			  - cpus
+			checks:
+			  environment:
+			  - HOME
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.NoError(t, err)
+
+	v1 := cfg.(*V1EnvsnapConfig)
+	assert.Equal(t, 1, v1.Version)
+	assert.Empty(t, v1.Extends)
+	assert.Equal(t, []string{"os", "arch", "cpus"}, v1.System.Core.Values())
+	assert.Equal(t, []string{"PATH"}, v1.Environment.Variables.Values())
+	assert.Equal(t, []string{"version"}, v1.Python.Core.Values())
+	assert.Equal(t, []string{"HOME"}, v1.Checks.Environment)
+}
+
+func TestLoadConfig_ExtendsCycle(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"a.yml": `
+			version: 1
+			extends: [b.yml]
+		`,
+		"b.yml": `
+			version: 1
+			extends: [a.yml]
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "a.yml"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Equal(t,
+		filepath.Join(dir, "b.yml")+": config extends itself: "+
+			filepath.Join(dir, "a.yml")+" -> "+filepath.Join(dir, "b.yml")+" -> "+filepath.Join(dir, "a.yml"),
+		err.Error(),
+	)
+}
+
+func TestLoadConfig_ExtendsInvalid(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base.yml": `
+			version: 1
+			enviroment:
+			  variables: [PATH]
+		`,
+		".envsnap": `
+			version: 1
+			extends: [base.yml]
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "base.yml")+": ")
+	assert.Contains(t, err.Error(), "field enviroment not found")
+}
+
+func TestLoadConfig_ExtendsMissing(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		".envsnap": `
+			version: 1
+			extends: [missing.yml]
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Equal(t,
+		filepath.Join(dir, ".envsnap")+": failed to load extended config "+filepath.Join(dir, "missing.yml")+": .envsnap file not found",
+		err.Error(),
+	)
+}
+
+func TestResolveExtendsPath(t *testing.T) {
+	var tests = []struct {
+		from string
+		ext  string
+		path string
+		err  bool
+	}{
+		{"/a/b/.envsnap", "base.yml", "/a/b/base.yml", false},
+		{"/a/b/.envsnap", "../base.yml", "/a/base.yml", false},
+		{"/a/b/.envsnap", "/c/base.yml", "/c/base.yml", false},
+		{"/a/b/.envsnap", "github.com/foo/bar", "github.com/foo/bar", false},
+		{"/a/b/.envsnap", "file:///c/base.yml", "file:///c/base.yml", false},
+		{"github.com/foo/bar", "github.com/foo/bar//base.yml@v1", "github.com/foo/bar//base.yml@v1", false},
+		{"github.com/foo/bar", "base.yml", "github.com/foo/bar//base.yml", false},
+		{"github.com/foo/bar//cfg/.envsnap@v1", "base.yml", "github.com/foo/bar//cfg/base.yml@v1", false},
+		{"https://example.com/cfg/.envsnap", "../base.yml", "https://example.com/base.yml", false},
+		{"https://example.com/cfg/.envsnap", "https://example.com/base.yml", "https://example.com/base.yml", false},
+		{"git+https://example.com/foo.git", "base.yml", "git+https://example.com/foo.git//base.yml", false},
+		{"github.com/foo", "base.yml", "", true},
+		{"github.com/foo/bar", "github.com/foo/baz@v1", "", true},
+		{"github.com/foo/bar", "gitlab.com/foo/bar", "", true},
+		{"github.com/foo/bar", "../../base.yml", "", true},
+		{"github.com/foo/bar", "file:///etc/base.yml", "", true},
+		{"github.com/foo/bar", "git+--upload-pack=touch /tmp/x@.", "", true},
+		{"github.com/foo/bar", "git+https://example.com/foo.git", "", true},
+		{"git+https://example.com/foo.git", "git+https://example.com/foo.git//base.yml", "", true},
+		{"https://example.com/cfg/.envsnap", "https://other.com/base.yml", "", true},
+		{"https://example.com/cfg/.envsnap", "//other.com/base.yml", "", true},
+		{"https://example.com/cfg/.envsnap", "http://example.com/base.yml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.ext, func(t *testing.T) {
+			path, err := resolveExtendsPath(tt.from, tt.ext)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	base := map[interface{}]interface{}{
+		"version": 1,
+		"system": map[interface{}]interface{}{
+			"core": []interface{}{"os", "arch"},
+		},
+		"checks": map[interface{}]interface{}{
+			"versions": map[interface{}]interface{}{
+				"go.core.version":     ">=1.12",
+				"python.core.version": ">=3.6",
+			},
+		},
+		"exec": map[interface{}]interface{}{
+			"run": []interface{}{"ls"},
+		},
+		"outputs": []interface{}{
+			map[interface{}]interface{}{"format": "md"},
+		},
+	}
+	override := map[interface{}]interface{}{
+		"version": 1,
+		"system": map[interface{}]interface{}{
+			"core": []interface{}{"arch", "cpus"},
+		},
+		"checks": map[interface{}]interface{}{
+			"versions": map[interface{}]interface{}{
+				"go.core.version": ">=1.13",
+			},
+		},
+		"exec": "not-a-mapping",
+		"outputs": []interface{}{
+			map[interface{}]interface{}{"format": "gfm"},
+		},
+	}
+
+	merged := mergeConfig(base, override)
+	assert.Equal(t, map[interface{}]interface{}{
+		"version": 1,
+		"system": map[interface{}]interface{}{
+			"core": []interface{}{"os", "arch", "cpus"},
+		},
+		"checks": map[interface{}]interface{}{
+			"versions": map[interface{}]interface{}{
+				"go.core.version":     ">=1.13",
+				"python.core.version": ">=3.6",
+			},
+		},
+		"exec": "not-a-mapping",
+		"outputs": []interface{}{
+			map[interface{}]interface{}{"format": "gfm"},
+		},
+	}, merged)
+
+	// The inputs should not be modified.
+	assert.Equal(t, []interface{}{"os", "arch"}, base["system"].(map[interface{}]interface{})["core"])
+	assert.Equal(t, ">=1.12", base["checks"].(map[interface{}]interface{})["versions"].(map[interface{}]interface{})["go.core.version"])
+}
+
+func TestLoadConfig_ExtendsVersion(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"v1.yml": `
+			version: 1
+			system:
+			  core: [os]
+		`,
+		"v2.yml": `
+			version: 2
+			extends: [v1.yml]
+			exec:
+			  run:
+			  - value: uname
+			    label: kernel
+		`,
+		".envsnap": `
+			version: 1
+			extends: [v2.yml]
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "v2.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"os"}, cfg.(*V1EnvsnapConfig).System.Core.Values())
+
+	cfg, err = LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "config version 1 can not extend a config with version 2")
+}
+
+func TestLoadConfig_ExtendsTemplate(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base/base.yml": `
+			version: 1
+			template: snapshot.tmpl
+		`,
+		".envsnap": `
+			version: 1
+			extends: [base/base.yml]
+		`,
+		"override.yml": `
+			version: 1
+			extends: [base/base.yml]
+			template: https://example.com/snapshot.tmpl
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "base", "snapshot.tmpl"), cfg.TemplatePath())
+
+	cfg, err = LoadConfig(filepath.Join(dir, "override.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/snapshot.tmpl", cfg.TemplatePath())
+}
+
+func TestLoadConfig_ExtendsOutputs(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base.yml": `
+			version: 1
+			outputs:
+			  - format: md
+		`,
+		".envsnap": `
+			version: 1
+			extends: [base.yml]
+		`,
+		"override.yml": `
+			version: 1
+			extends: [base.yml]
+			outputs:
+			  - format: gfm
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "md"}}, cfg.ConfigOutputs())
+
+	// The outputs of the extending config replace those of its base.
+	cfg, err = LoadConfig(filepath.Join(dir, "override.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "gfm"}}, cfg.ConfigOutputs())
+
+	outputs, err := resolveOutputs(cfg.ConfigOutputs(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "gfm"}}, outputs)
+}
+
+func TestLoadConfig_ExtendsRemoteOutputs(t *testing.T) {
+	defer cliWarnings.Clear()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, heredoc.Doc(`
+			version: 1
+			outputs:
+			  - format: json
+			    file: /etc/profile.d/envsnap.sh
+			system:
+			  core: [os]
+		`))
+	}))
+	defer server.Close()
+
+	dir := writeConfigs(t, map[string]string{
+		".envsnap": fmt.Sprintf(`
+			version: 1
+			extends: [%s/.envsnap]
+		`, server.URL),
+	})
+	defer os.RemoveAll(dir)
+	remoteCache.Dir = filepath.Join(dir, "cache")
+	defer func() { remoteCache.Dir = "" }()
+
+	// The outputs set by the remote config are ignored.
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.ConfigOutputs())
+	assert.Equal(t, []string{"os"}, cfg.(*V1EnvsnapConfig).System.Core.Values())
+	assert.Equal(t, []string{"ignoring the outputs set by remote config " + server.URL + "/.envsnap"}, cliWarnings.Warnings["config"])
+}
@@ -0,0 +1,485 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	log "github.com/sirupsen/logrus"
+)
+
+// Environment variables which hold the tokens used to authenticate with
+// remote config sources. Authentication is optional; if a token is not set,
+// requests are made unauthenticated.
+const (
+	githubTokenEnv    = "GITHUB_TOKEN"
+	gitlabTokenEnv    = "GITLAB_TOKEN"
+	bitbucketTokenEnv = "BITBUCKET_TOKEN"
+	gitTokenEnv       = "ENVSNAP_GIT_TOKEN"
+	httpTokenEnv      = "ENVSNAP_HTTP_TOKEN"
+)
+
+// Environment variables which hold the host that the git and HTTP tokens are
+// sent to. Those sources are not tied to a single host, so their tokens are
+// only sent to the host they were configured for, and not at all if it is not
+// set.
+const (
+	gitTokenHostEnv  = "ENVSNAP_GIT_TOKEN_HOST"
+	httpTokenHostEnv = "ENVSNAP_HTTP_TOKEN_HOST"
+)
+
+// ConfigFetcher defines an interface for fetching config files from a
+// remote source.
+type ConfigFetcher interface {
+	Fetch(ref RemoteRef) (*RemoteConfig, error)
+}
+
+// RemoteConfig is a config file fetched from a remote source.
+type RemoteConfig struct {
+	Ref RemoteRef
+
+	// Commit is the commit SHA which the ref resolved to when the config was
+	// fetched. It is empty for sources which are not repositories.
+	Commit string
+	Data   []byte
+
+	// SignedBy is the ID of the trusted key which signed the config, if
+	// its signature was verified.
+	SignedBy string
+}
+
+// Source gets the description of where the config was fetched from, for
+// recording in a snapshot.
+func (c *RemoteConfig) Source() ConfigSource {
+	return ConfigSource{
+		Ref:      c.Ref.String(),
+		Commit:   c.Commit,
+		Digest:   fmt.Sprintf("sha256:%x", sha256.Sum256(c.Data)),
+		SignedBy: c.SignedBy,
+	}
+}
+
+// configFetchers maps each kind of remote config source to the fetcher which
+// is used to fetch configs from it.
+var configFetchers = map[string]ConfigFetcher{
+	remoteGithub:    &GithubFetcher{},
+	remoteGitlab:    &GitlabFetcher{},
+	remoteBitbucket: &BitbucketFetcher{},
+	remoteGit:       &GitFetcher{},
+	remoteHTTP:      &HTTPFetcher{},
+	remoteFile:      &FileFetcher{},
+}
+
+// fetchConfig fetches a remote config file, using the remote config cache
+// (see RemoteCache).
+//
+// If any keys are trusted to sign remote configs (see LoadTrustedKeys), the
+// config must have a valid detached signature by one of those keys.
+func fetchConfig(s string) (*RemoteConfig, error) {
+	ref, err := ParseRemoteRef(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := remoteCache.Fetch(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := LoadTrustedKeys("")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		key, err := verifyRemoteConfig(cfg, keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", ref, err)
+		}
+		cfg.SignedBy = key.String()
+	}
+
+	log.WithFields(log.Fields{
+		"ref":    ref.String(),
+		"commit": cfg.Commit,
+		"signer": cfg.SignedBy,
+	}).Debug("resolved remote config")
+	return cfg, nil
+}
+
+// fetchRemote fetches a remote config from its source, bypassing the cache.
+func fetchRemote(ref RemoteRef) (*RemoteConfig, error) {
+	fetcher, ok := configFetchers[ref.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, ref)
+	}
+	log.WithFields(log.Fields{
+		"kind": ref.Kind,
+		"repo": ref.Repo,
+		"path": ref.Path,
+		"ref":  ref.Ref,
+	}).Debug("fetching remote config")
+	return fetcher.Fetch(ref)
+}
+
+// GithubFetcher fetches configs from GitHub repositories via the GitHub API.
+type GithubFetcher struct {
+	// BaseURL overrides the GitHub API URL, e.g. for GitHub Enterprise.
+	BaseURL string
+}
+
+// Fetch the referenced config file from GitHub.
+func (f *GithubFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	parts := strings.Split(ref.Repo, "/")
+	if len(parts) != 2 {
+		return nil, ErrInvalidGithubURL
+	}
+
+	base := "https://api.github.com/"
+	if f.BaseURL != "" {
+		base = strings.TrimSuffix(f.BaseURL, "/") + "/"
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	ghc := github.NewClient(tokenClient(githubTokenEnv, "token", u.Host))
+	ghc.BaseURL = u
+
+	// Resolve the ref to a commit first, so the config is fetched from
+	// exactly the commit which is reported.
+	gitRef := ref.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	sha, _, err := ghc.Repositories.GetCommitSHA1(context.Background(), parts[0], parts[1], gitRef, "")
+	if err != nil {
+		return nil, err
+	}
+
+	content, _, _, err := ghc.Repositories.GetContents(
+		context.Background(),
+		parts[0],
+		parts[1],
+		ref.Path,
+		&github.RepositoryContentGetOptions{
+			Ref: sha,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("%s is not a file", ref.Path)
+	}
+	ctnt, err := content.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteConfig{Ref: ref, Commit: sha, Data: []byte(ctnt)}, nil
+}
+
+// GitlabFetcher fetches configs from GitLab repositories via the GitLab API.
+type GitlabFetcher struct {
+	// BaseURL overrides the GitLab URL, e.g. for self-hosted GitLab.
+	BaseURL string
+}
+
+// Fetch the referenced config file from GitLab.
+func (f *GitlabFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	base := f.BaseURL
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	gitRef := ref.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+
+	project := fmt.Sprintf("%s/api/v4/projects/%s", strings.TrimSuffix(base, "/"), url.PathEscape(ref.Repo))
+
+	header := http.Header{}
+	if token := os.Getenv(gitlabTokenEnv); token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+
+	data, err := httpGet(fmt.Sprintf("%s/repository/commits/%s", project, url.PathEscape(gitRef)), header)
+	if err != nil {
+		return nil, err
+	}
+	var commit struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &commit); err != nil {
+		return nil, err
+	}
+
+	data, err = httpGet(fmt.Sprintf("%s/repository/files/%s/raw?ref=%s", project, url.PathEscape(ref.Path), commit.ID), header)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteConfig{Ref: ref, Commit: commit.ID, Data: data}, nil
+}
+
+// BitbucketFetcher fetches configs from Bitbucket Cloud repositories via the
+// Bitbucket API.
+type BitbucketFetcher struct {
+	// BaseURL overrides the Bitbucket API URL.
+	BaseURL string
+}
+
+// Fetch the referenced config file from Bitbucket.
+func (f *BitbucketFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	base := f.BaseURL
+	if base == "" {
+		base = "https://api.bitbucket.org"
+	}
+	base = strings.TrimSuffix(base, "/") + "/2.0/repositories/" + ref.Repo
+
+	header := http.Header{}
+	if token := os.Getenv(bitbucketTokenEnv); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	// The Bitbucket API requires a ref, so if none is specified, look up
+	// the repository's main branch.
+	gitRef := ref.Ref
+	if gitRef == "" {
+		data, err := httpGet(base, header)
+		if err != nil {
+			return nil, err
+		}
+		var repo struct {
+			MainBranch struct {
+				Name string `json:"name"`
+			} `json:"mainbranch"`
+		}
+		if err := json.Unmarshal(data, &repo); err != nil {
+			return nil, err
+		}
+		gitRef = repo.MainBranch.Name
+	}
+
+	data, err := httpGet(fmt.Sprintf("%s/commit/%s", base, url.PathEscape(gitRef)), header)
+	if err != nil {
+		return nil, err
+	}
+	var commit struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &commit); err != nil {
+		return nil, err
+	}
+
+	data, err = httpGet(fmt.Sprintf("%s/src/%s/%s", base, commit.Hash, ref.Path), header)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteConfig{Ref: ref, Commit: commit.Hash, Data: data}, nil
+}
+
+// GitFetcher fetches configs from any git remote by shallow fetching the ref
+// from the remote.
+type GitFetcher struct{}
+
+// Fetch the referenced config file from a git remote.
+func (f *GitFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	if !binExists("git") {
+		return nil, fmt.Errorf("git executable not found")
+	}
+	if isOptionLike(ref.Repo) || isOptionLike(ref.Ref) {
+		return nil, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, ref)
+	}
+
+	dir, err := ioutil.TempDir("", "envsnap-git")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	gitRef := ref.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+
+	// The token is scoped to the URL of the remote, and redirects are not
+	// followed, so that it is not sent to any other host.
+	var auth []string
+	if token := scopedToken(gitTokenEnv, gitTokenHostEnv, ref.Repo); token != "" {
+		u, _ := url.Parse(ref.Repo)
+		auth = []string{
+			"-c", fmt.Sprintf("http.%s://%s/.extraHeader=Authorization: Bearer %s", u.Scheme, u.Host, token),
+			"-c", "http.followRedirects=false",
+		}
+	}
+
+	cmds := [][]string{
+		{"init", "--quiet", dir},
+		append(auth, "-C", dir, "fetch", "--quiet", "--depth", "1", "--", ref.Repo, gitRef),
+	}
+	for _, args := range cmds {
+		if _, stderr, err := runCommand("git", args...); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s from %s: %s", gitRef, ref.Repo, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	sha, _, err := runCommand("git", "-C", dir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, err
+	}
+	stdout, stderr, err := runCommand("git", "-C", dir, "show", "FETCH_HEAD:"+ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from %s: %s", ref.Path, ref.Repo, strings.TrimSpace(stderr.String()))
+	}
+	return &RemoteConfig{Ref: ref, Commit: strings.TrimSpace(sha.String()), Data: stdout.Bytes()}, nil
+}
+
+// HTTPFetcher fetches configs from plain HTTP(S) URLs.
+type HTTPFetcher struct{}
+
+// Fetch the referenced config file from its URL.
+func (f *HTTPFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	header := http.Header{}
+	if token := scopedToken(httpTokenEnv, httpTokenHostEnv, ref.Repo); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	data, err := httpGet(ref.Repo, header)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteConfig{Ref: ref, Data: data}, nil
+}
+
+// FileFetcher fetches configs from file:// URLs.
+type FileFetcher struct{}
+
+// Fetch the referenced config file from the local filesystem.
+func (f *FileFetcher) Fetch(ref RemoteRef) (*RemoteConfig, error) {
+	if _, err := os.Stat(ref.Repo); os.IsNotExist(err) {
+		return nil, ErrNoConfig
+	}
+	data, err := ioutil.ReadFile(ref.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteConfig{Ref: ref, Data: data}, nil
+}
+
+// httpGet is a helper to make a GET request to the given URL and read the
+// response body. Responses with a non-2xx status are returned as errors.
+//
+// The header may hold credentials for the host of the URL, so it is not sent
+// along if the request is redirected to another host.
+func httpGet(u string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+				for key := range header {
+					req.Header.Del(key)
+				}
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return data, nil
+}
+
+// scopedToken gets the token held by the tokenEnv environment variable, if it
+// may be sent to the given URL: the host of the URL must be the host held by
+// the hostEnv environment variable. If the token is set but not sent, a
+// warning is logged.
+func scopedToken(tokenEnv, hostEnv, u string) string {
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return ""
+	}
+	host := os.Getenv(hostEnv)
+	if host == "" {
+		log.Warnf("%s is set, but not sent since %s is not set", tokenEnv, hostEnv)
+		return ""
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return ""
+	}
+	if !strings.EqualFold(parsed.Host, host) && !strings.EqualFold(parsed.Hostname(), host) {
+		log.WithFields(log.Fields{
+			"host":  parsed.Host,
+			"token": tokenEnv,
+		}).Debug("not sending token to host it is not configured for")
+		return ""
+	}
+	return token
+}
+
+// isOptionLike checks whether the string could be taken for a command line
+// option if it were passed as an argument to a command.
+func isOptionLike(s string) bool {
+	return strings.HasPrefix(s, "-")
+}
+
+// tokenClient creates an HTTP client which authenticates requests to the
+// given host with the token held by the given environment variable. If the
+// environment variable is not set, nil is returned so that the default client
+// is used.
+func tokenClient(env, scheme, host string) *http.Client {
+	token := os.Getenv(env)
+	if token == "" {
+		return nil
+	}
+	return &http.Client{
+		Transport: &tokenTransport{
+			token:  token,
+			scheme: scheme,
+			host:   host,
+		},
+	}
+}
+
+// tokenTransport is an http.RoundTripper which sets the Authorization header
+// on all requests to a host, so that the token is not sent along when a
+// request is redirected elsewhere.
+type tokenTransport struct {
+	token  string
+	scheme string
+	host   string
+}
+
+// RoundTrip sets the Authorization header on the request, if it is to the
+// host of the token, and executes it.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if strings.EqualFold(req.URL.Host, t.host) {
+		req.Header.Set("Authorization", t.scheme+" "+t.token)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,325 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRemoteConfig = "version: 1\nenvironment:\n  variables:\n    - HOME\n"
+	testCommit       = "0123456789abcdef0123456789abcdef01234567"
+)
+
+func TestGithubFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token abc", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/foo/bar/commits/v1":
+			fmt.Fprint(w, testCommit)
+		case "/repos/foo/bar/contents/cfg/.envsnap":
+			assert.Equal(t, testCommit, r.URL.Query().Get("ref"))
+			fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": %q}`,
+				base64.StdEncoding.EncodeToString([]byte(testRemoteConfig)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(githubTokenEnv, "abc")
+	defer os.Unsetenv(githubTokenEnv)
+
+	f := &GithubFetcher{BaseURL: server.URL}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: "cfg/.envsnap", Ref: "v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+}
+
+func TestGithubFetcher_Fetch_Error(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	f := &GithubFetcher{BaseURL: server.URL}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: ".envsnap"})
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
+
+func TestGitlabFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "abc", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/repository/commits/HEAD":
+			fmt.Fprintf(w, `{"id": %q}`, testCommit)
+		case "/api/v4/projects/group%2Fproject/repository/files/cfg%2F.envsnap/raw":
+			assert.Equal(t, testCommit, r.URL.Query().Get("ref"))
+			fmt.Fprint(w, testRemoteConfig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(gitlabTokenEnv, "abc")
+	defer os.Unsetenv(gitlabTokenEnv)
+
+	f := &GitlabFetcher{BaseURL: server.URL}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteGitlab, Repo: "group/project", Path: "cfg/.envsnap"})
+	assert.NoError(t, err)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+}
+
+func TestBitbucketFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer abc", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/2.0/repositories/foo/bar":
+			fmt.Fprint(w, `{"mainbranch": {"name": "main"}}`)
+		case "/2.0/repositories/foo/bar/commit/main":
+			fmt.Fprintf(w, `{"hash": %q}`, testCommit)
+		case "/2.0/repositories/foo/bar/src/" + testCommit + "/.envsnap":
+			fmt.Fprint(w, testRemoteConfig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(bitbucketTokenEnv, "abc")
+	defer os.Unsetenv(bitbucketTokenEnv)
+
+	f := &BitbucketFetcher{BaseURL: server.URL}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteBitbucket, Repo: "foo/bar", Path: ".envsnap"})
+	assert.NoError(t, err)
+	assert.Equal(t, testCommit, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+}
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cfg/.envsnap", r.URL.Path)
+		fmt.Fprint(w, testRemoteConfig)
+	}))
+	defer server.Close()
+
+	f := &HTTPFetcher{}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteHTTP, Repo: server.URL + "/cfg/.envsnap"})
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+}
+
+func TestHTTPFetcher_Fetch_Error(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	f := &HTTPFetcher{}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteHTTP, Repo: server.URL + "/.envsnap"})
+	assert.EqualError(t, err, "GET "+server.URL+"/.envsnap: 404 Not Found")
+	assert.Nil(t, cfg)
+}
+
+func TestHTTPFetcher_Fetch_Token(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		fmt.Fprint(w, testRemoteConfig)
+	}))
+	defer server.Close()
+
+	os.Setenv(httpTokenEnv, "abc")
+	defer os.Unsetenv(httpTokenEnv)
+	defer os.Unsetenv(httpTokenHostEnv)
+
+	f := &HTTPFetcher{}
+	ref := RemoteRef{Kind: remoteHTTP, Repo: server.URL + "/.envsnap"}
+
+	// The token is not sent unless its host is configured.
+	_, err := f.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Empty(t, auth)
+
+	os.Setenv(httpTokenHostEnv, "example.com")
+	_, err = f.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Empty(t, auth)
+
+	os.Setenv(httpTokenHostEnv, strings.TrimPrefix(server.URL, "http://"))
+	_, err = f.Fetch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abc", auth)
+}
+
+func TestHTTPGet_Redirect(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		fmt.Fprint(w, testRemoteConfig)
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "abc", r.Header.Get("PRIVATE-TOKEN"))
+		http.Redirect(w, r, other.URL+"/.envsnap", http.StatusFound)
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", "abc")
+	data, err := httpGet(server.URL+"/.envsnap", header)
+	assert.NoError(t, err)
+	assert.Equal(t, testRemoteConfig, string(data))
+}
+
+func TestScopedToken(t *testing.T) {
+	os.Setenv(gitTokenEnv, "abc")
+	os.Setenv(gitTokenHostEnv, "git.example.com")
+	defer os.Unsetenv(gitTokenEnv)
+	defer os.Unsetenv(gitTokenHostEnv)
+
+	var tests = []struct {
+		url   string
+		token string
+	}{
+		{"https://git.example.com/repo.git", "abc"},
+		{"https://GIT.example.com:443/repo.git", "abc"},
+		{"http://git.example.com/repo.git", "abc"},
+		{"https://other.example.com/repo.git", ""},
+		{"https://git.example.com.evil.com/repo.git", ""},
+		{"ssh://git.example.com/repo.git", ""},
+		{"git@git.example.com:repo.git", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.token, scopedToken(gitTokenEnv, gitTokenHostEnv, tt.url))
+		})
+	}
+}
+
+func TestGitFetcher_Fetch_OptionLike(t *testing.T) {
+	if !binExists("git") {
+		t.Skip("git not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pwned := filepath.Join(dir, "pwned")
+
+	f := &GitFetcher{}
+	for _, ref := range []RemoteRef{
+		{Kind: remoteGit, Repo: "--upload-pack=touch " + pwned, Path: ".envsnap", Ref: "."},
+		{Kind: remoteGit, Repo: "file://" + dir, Path: ".envsnap", Ref: "--upload-pack=touch " + pwned},
+	} {
+		cfg, err := f.Fetch(ref)
+		assert.Error(t, err)
+		assert.Nil(t, cfg)
+		assert.False(t, fileExists(pwned))
+	}
+}
+
+func TestGitFetcher_Fetch(t *testing.T) {
+	if !binExists("git") {
+		t.Skip("git not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "cfg"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cfg", ".envsnap"), []byte(testRemoteConfig), 0644))
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	sha, err := cmd.Output()
+	assert.NoError(t, err)
+
+	f := &GitFetcher{}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteGit, Repo: "file://" + dir, Path: "cfg/.envsnap", Ref: "v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(sha)), cfg.Commit)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+
+	cfg, err = f.Fetch(RemoteRef{Kind: remoteGit, Repo: "file://" + dir, Path: "missing.yml"})
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+}
+
+func TestFileFetcher_Fetch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".envsnap")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testRemoteConfig), 0644))
+
+	f := &FileFetcher{}
+	cfg, err := f.Fetch(RemoteRef{Kind: remoteFile, Repo: path})
+	assert.NoError(t, err)
+	assert.Equal(t, testRemoteConfig, string(cfg.Data))
+
+	cfg, err = f.Fetch(RemoteRef{Kind: remoteFile, Repo: filepath.Join(dir, "missing")})
+	assert.Equal(t, ErrNoConfig, err)
+	assert.Nil(t, cfg)
+}
+
+func TestLoadConfig_Remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cfg/.envsnap":
+			fmt.Fprint(w, "version: 1\nextends:\n  - base.yml\nenvironment:\n  variables:\n    - HOME\n")
+		case "/cfg/base.yml":
+			fmt.Fprint(w, "version: 1\nenvironment:\n  variables:\n    - PATH\nexec:\n  run:\n    - go version\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	remoteCache.Dir = dir
+	defer func() { remoteCache.Dir = "" }()
+
+	cfg, err := LoadConfig(server.URL + "/cfg/.envsnap")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATH", "HOME"}, cfg.(*V1EnvsnapConfig).Environment.Variables.Values())
+
+	assert.Equal(t, []ConfigSource{
+		{
+			Ref:    server.URL + "/cfg/.envsnap",
+			Digest: "sha256:0778a947390aa659f29c792e54de61655c81e8f110a9518c34a74e76a4202358",
+		},
+		{
+			Ref:    server.URL + "/cfg/base.yml",
+			Digest: "sha256:d6fa3a9758106cd29e6445ec6b71f96e57e235a094b18adf16aba32890494cee",
+			exec:   []string{"go version"},
+		},
+	}, cfg.(*V1EnvsnapConfig).sources)
+}
@@ -0,0 +1,253 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// flatValue is a single key/value pair of a flattened snapshot.
+type flatValue struct {
+	Key   string
+	Value interface{}
+}
+
+// flatSection is a section of a flattened snapshot, named by its path.
+type flatSection struct {
+	Path   []string
+	Values []flatValue
+}
+
+// add a value to the section, if it is set.
+func (s *flatSection) add(key string, value interface{}) {
+	if value == "" || value == 0 {
+		return
+	}
+	s.Values = append(s.Values, flatValue{Key: key, Value: value})
+}
+
+// addMap adds the values of a map to the section, sorted by key.
+func (s *flatSection) addMap(m map[string]string) {
+	var k []string
+	for key := range m {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	for _, key := range k {
+		s.Values = append(s.Values, flatValue{Key: key, Value: m[key]})
+	}
+}
+
+// flatten the result into sections of key/value pairs for the flat output
+// formats (toml, ini and env). The rules for flattening are:
+//
+//   - The snapshot metadata and each source are a section, named by their
+//     key in the JSON output, and in the order in which they are rendered.
+//     Empty sources are left out.
+//   - Fields are keyed by their name in the JSON output, in the same order.
+//   - Environment variables and exec commands are the keys of their section,
+//     sorted. Exec output has its trailing newlines removed.
+//   - Python dependencies are a nested section, "python.dependencies".
+//
+// The config sources of the snapshot are not included, since they do not
+// map onto flat key/value pairs.
+func (r *V1EnvsnapResult) flatten() []flatSection {
+	d := r.data()
+
+	var sections []flatSection
+	if d.Meta != nil {
+		s := flatSection{Path: []string{"meta"}}
+		s.add("captured_at", d.Meta.Captured())
+		s.add("duration", d.Meta.Duration)
+		s.add("envsnap_version", d.Meta.Version)
+		s.add("envsnap_commit", d.Meta.Commit)
+		s.add("config", d.Meta.Config)
+		s.add("config_digest", d.Meta.ConfigDigest)
+		s.add("hostname", d.Meta.Hostname)
+		sections = append(sections, s)
+	}
+	if d.System != nil {
+		s := flatSection{Path: []string{"system"}}
+		s.add("os", d.System.OS)
+		s.add("arch", d.System.Arch)
+		s.add("cpus", d.System.CPUs.value())
+		s.add("kernel", d.System.Kernel)
+		s.add("kernel_version", d.System.KernelVersion)
+		s.add("processor", d.System.Processor)
+		sections = append(sections, s)
+	}
+	if d.Environment != nil {
+		s := flatSection{Path: []string{"environment"}}
+		s.addMap(d.Environment.Env)
+		sections = append(sections, s)
+	}
+	if d.Exec != nil {
+		s := flatSection{Path: []string{"exec"}}
+		output := map[string]string{}
+		for cmd, out := range d.Exec.Exec {
+			output[cmd] = strings.TrimRight(out, "\n")
+		}
+		s.addMap(output)
+		sections = append(sections, s)
+	}
+	if d.Python != nil {
+		s := flatSection{Path: []string{"python"}}
+		s.add("version", d.Python.Version)
+		s.add("py2", d.Python.VersionPy2)
+		s.add("py3", d.Python.VersionPy3)
+		if len(s.Values) != 0 {
+			sections = append(sections, s)
+		}
+		if len(d.Python.Deps) != 0 {
+			deps := flatSection{Path: []string{"python", "dependencies"}}
+			deps.addMap(d.Python.Deps)
+			sections = append(sections, deps)
+		}
+	}
+	if d.Golang != nil {
+		s := flatSection{Path: []string{"golang"}}
+		s.add("version", d.Golang.Version)
+		s.add("goroot", d.Golang.Goroot)
+		s.add("gopath", d.Golang.Gopath)
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+// bareKey matches keys which do not need to be quoted in TOML.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey formats a TOML key, quoting it if it is not a bare key.
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString formats a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tomlValue formats a TOML value.
+func tomlValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return tomlString(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// toTOML renders the flattened snapshot as TOML.
+func toTOML(version int, sections []flatSection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "version = %d\n", version)
+	for _, s := range sections {
+		var path []string
+		for _, p := range s.Path {
+			path = append(path, tomlKey(p))
+		}
+		fmt.Fprintf(&b, "\n[%s]\n", strings.Join(path, "."))
+		for _, v := range s.Values {
+			fmt.Fprintf(&b, "%s = %s\n", tomlKey(v.Key), tomlValue(v.Value))
+		}
+	}
+	return b.String()
+}
+
+// iniPlain matches INI keys and values which can be written without quoting:
+// a single line without leading or trailing whitespace, quotes, or any of the
+// characters which INI parsers treat specially.
+var iniPlain = regexp.MustCompile(`^[^\s"'=:;#\[\]\\]([^\n\r"=:;#\[\]\\]*[^\s"=:;#\[\]\\])?$`)
+
+// iniString formats an INI key or value, quoting it with Go-style escapes if
+// it can not be written as-is.
+func iniString(value interface{}) string {
+	s := fmt.Sprint(value)
+	if iniPlain.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// toINI renders the flattened snapshot as INI.
+func toINI(version int, sections []flatSection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "version = %d\n", version)
+	for _, s := range sections {
+		fmt.Fprintf(&b, "\n[%s]\n", strings.Join(s.Path, "."))
+		for _, v := range s.Values {
+			fmt.Fprintf(&b, "%s = %s\n", iniString(v.Key), iniString(v.Value))
+		}
+	}
+	return b.String()
+}
+
+// envInvalid matches the runs of characters which are not allowed in the
+// names of environment variables.
+var envInvalid = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// envName formats the name of an environment variable from the parts of a
+// key: the parts are joined with underscores and upper-cased, and every run
+// of characters which are not letters or digits is replaced by a single
+// underscore.
+func envName(parts ...string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	return strings.Trim(envInvalid.ReplaceAllString(name, "_"), "_")
+}
+
+// shellQuote quotes a value so that it can be used as a single word in a
+// POSIX shell.
+func shellQuote(value interface{}) string {
+	return "'" + strings.Replace(fmt.Sprint(value), "'", `'\''`, -1) + "'"
+}
+
+// toEnv renders the flattened snapshot as dotenv, with shell-quoted values
+// so that it can be sourced by a shell. Names are formed from the section
+// path and the key (see envName). If two keys form the same name, the later
+// ones are suffixed with "_2", "_3", and so on.
+func toEnv(sections []flatSection) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, s := range sections {
+		for _, v := range s.Values {
+			base := envName(append(append([]string{}, s.Path...), v.Key)...)
+			name := base
+			for i := 2; seen[name]; i++ {
+				name = fmt.Sprintf("%s_%d", base, i)
+			}
+			seen[name] = true
+			fmt.Fprintf(&b, "%s=%s\n", name, shellQuote(v.Value))
+		}
+	}
+	return b.String()
+}
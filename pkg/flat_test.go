@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+// flatTestResult creates a result with every kind of value which is
+// flattened by the flat output formats.
+func flatTestResult() V1EnvsnapResult {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+	sys.CPUs = 4
+
+	env := NewEnvResult()
+	env.Env["PATH"] = "/bin:/usr/bin"
+	env.Env["EMPTY"] = ""
+
+	exec := NewExecResult()
+	exec.Exec["echo 'hi'"] = "hi\n"
+	exec.Exec["echo-hi"] = "a\nb\n"
+
+	python := NewPythonResult()
+	python.Deps["requests"] = "2.22.0"
+
+	res := NewV1EnvsnapResult()
+	res.System = sys
+	res.Environment = env
+	res.Exec = exec
+	res.Python = python
+	res.Golang = NewGolangResult()
+	return res
+}
+
+func TestV1EnvsnapResult_flatten(t *testing.T) {
+	res := flatTestResult()
+
+	assert.Equal(t, []flatSection{
+		{Path: []string{"system"}, Values: []flatValue{{"os", "linux"}, {"cpus", 4}}},
+		{Path: []string{"environment"}, Values: []flatValue{{"EMPTY", ""}, {"PATH", "/bin:/usr/bin"}}},
+		{Path: []string{"exec"}, Values: []flatValue{{"echo 'hi'", "hi"}, {"echo-hi", "a\nb"}}},
+		{Path: []string{"python", "dependencies"}, Values: []flatValue{{"requests", "2.22.0"}}},
+	}, res.flatten())
+}
+
+func TestV1EnvsnapResult_flatten_Meta(t *testing.T) {
+	res := NewV1EnvsnapResult()
+	res.Meta = &SnapshotMeta{
+		CapturedAt:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Version:      "1.2.3",
+		ConfigDigest: "sha256:def456",
+	}
+
+	assert.Equal(t, []flatSection{
+		{Path: []string{"meta"}, Values: []flatValue{
+			{"captured_at", "2020-01-02T03:04:05Z"},
+			{"envsnap_version", "1.2.3"},
+			{"config_digest", "sha256:def456"},
+		}},
+	}, res.flatten())
+
+	data, err := res.String("env")
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		META_CAPTURED_AT='2020-01-02T03:04:05Z'
+		META_ENVSNAP_VERSION='1.2.3'
+		META_CONFIG_DIGEST='sha256:def456'
+	`), data)
+}
+
+func TestV1EnvsnapResult_String_TOML(t *testing.T) {
+	res := flatTestResult()
+
+	data, err := res.String("toml")
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		version = 1
+
+		[system]
+		os = "linux"
+		cpus = 4
+
+		[environment]
+		EMPTY = ""
+		PATH = "/bin:/usr/bin"
+
+		[exec]
+		"echo 'hi'" = "hi"
+		echo-hi = "a\nb"
+
+		[python.dependencies]
+		requests = "2.22.0"
+	`), data)
+}
+
+func TestV1EnvsnapResult_String_INI(t *testing.T) {
+	res := flatTestResult()
+
+	data, err := res.String("ini")
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		version = 1
+
+		[system]
+		os = linux
+		cpus = 4
+
+		[environment]
+		EMPTY = ""
+		PATH = "/bin:/usr/bin"
+
+		[exec]
+		echo 'hi' = hi
+		echo-hi = "a\nb"
+
+		[python.dependencies]
+		requests = 2.22.0
+	`), data)
+}
+
+func TestV1EnvsnapResult_String_Env(t *testing.T) {
+	res := flatTestResult()
+
+	data, err := res.String("env")
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		SYSTEM_OS='linux'
+		SYSTEM_CPUS='4'
+		ENVIRONMENT_EMPTY=''
+		ENVIRONMENT_PATH='/bin:/usr/bin'
+		EXEC_ECHO_HI='hi'
+		EXEC_ECHO_HI_2='a
+		b'
+		PYTHON_DEPENDENCIES_REQUESTS='2.22.0'
+	`), data)
+}
+
+func TestTomlString(t *testing.T) {
+	assert.Equal(t, `"a \"b\" \\ \t\n\u001B"`, tomlString("a \"b\" \\ \t\n\x1b"))
+}
+
+func TestIniString(t *testing.T) {
+	var tests = []struct {
+		value    interface{}
+		expected string
+	}{
+		{"a", "a"},
+		{1, "1"},
+		{"a b", "a b"},
+		{"it's", "it's"},
+		{" a", `" a"`},
+		{"a=b", `"a=b"`},
+		{"a;b", `"a;b"`},
+		{"'a'", `"'a'"`},
+		{"", `""`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, iniString(test.value))
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "SYSTEM_KERNEL_VERSION", envName("system", "kernel_version"))
+	assert.Equal(t, "EXEC_GO_VERSION", envName("exec", "go --version"))
+	assert.Equal(t, "EXEC_LS", envName("exec", "ls ."))
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, `'$HOME'`, shellQuote("$HOME"))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"text/template"
 
 	"github.com/MakeNowJust/heredoc"
@@ -11,9 +12,15 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// golangCoreOptions are the supported values for the "go.core" option.
+var golangCoreOptions = []string{
+	"version", "goroot", "gopath",
+}
+
 // GolangConfig defines the configuration for the "go" source.
 type GolangConfig struct {
-	Core []string `yaml:"core,omitempty"`
+	When *Condition `yaml:"when,omitempty"`
+	Core Items      `yaml:"core,omitempty"`
 }
 
 // Render the GolangConfig into its corresponding GolangResult.
@@ -22,8 +29,17 @@ func (c GolangConfig) Render() (Result, error) {
 	l.Debug("starting render")
 
 	result := NewGolangResult()
+	if !c.When.Applies() {
+		l.Debug("skipping render: condition does not apply")
+		return result, nil
+	}
 
-	for _, opt := range c.Core {
+	for _, item := range c.Core {
+		if !item.applies(l) {
+			continue
+		}
+		opt := item.Value
+		result.addMeta(opt, item)
 		switch opt {
 		case "version":
 			if !binExists("go") {
@@ -40,7 +56,7 @@ func (c GolangConfig) Render() (Result, error) {
 				cliWarnings.Add("go.core.version", "unable to determine version of go")
 				continue
 			}
-			result.Version = toSlice(stdout.Bytes())[2]
+			result.Version = item.redact(toSlice(stdout.Bytes())[2])
 
 		case "goroot":
 			if !binExists("go") {
@@ -57,7 +73,7 @@ func (c GolangConfig) Render() (Result, error) {
 				cliWarnings.Add("go.core.goroot", "unable to determine GOROOT")
 				continue
 			}
-			result.Goroot = normalize(stdout.Bytes())
+			result.Goroot = item.redact(normalize(stdout.Bytes()))
 
 		case "gopath":
 			if !binExists("go") {
@@ -74,7 +90,7 @@ func (c GolangConfig) Render() (Result, error) {
 				cliWarnings.Add("go.core.gopath", "unable to determine GOPATH")
 				continue
 			}
-			result.Gopath = normalize(stdout.Bytes())
+			result.Gopath = item.redact(normalize(stdout.Bytes()))
 
 		default:
 			return result, fmt.Errorf("unsupported core golang option: %s", opt)
@@ -107,6 +123,22 @@ func (r GolangResult) IsEmpty() bool {
 	return r.Version == "" && r.Goroot == "" && r.Gopath == ""
 }
 
+// withoutHidden gets a copy of the result without the hidden items.
+func (r GolangResult) withoutHidden() GolangResult {
+	for _, key := range r.hidden() {
+		switch key {
+		case "version":
+			r.Version = ""
+		case "goroot":
+			r.Goroot = ""
+		case "gopath":
+			r.Gopath = ""
+		}
+	}
+	r.Meta = r.visibleMeta()
+	return r
+}
+
 // Markdown renders the GolangResult to markdown.
 func (r GolangResult) Markdown() ([]byte, error) {
 	log.WithField("src", "golang").Debug("rendering to markdown")
@@ -116,10 +148,10 @@ func (r GolangResult) Markdown() ([]byte, error) {
 	}
 
 	md := heredoc.Doc(`
-		**Golang**{{ if .Version }}
-		- _version_: {{ .Version }}{{ end }}{{ if .Goroot }}
-		- _goroot_: {{ .Goroot }}{{ end }}{{ if .Gopath }}
-		- _gopath_: {{ .Gopath }}{{ end }}
+		**Golang**{{ if and .Version ($.Shown "version") }}
+		- _{{ $.Label "version" "version" }}_: {{ .Version }}{{ $.Describe "version" }}{{ end }}{{ if and .Goroot ($.Shown "goroot") }}
+		- _{{ $.Label "goroot" "goroot" }}_: {{ .Goroot }}{{ $.Describe "goroot" }}{{ end }}{{ if and .Gopath ($.Shown "gopath") }}
+		- _{{ $.Label "gopath" "gopath" }}_: {{ .Gopath }}{{ $.Describe "gopath" }}{{ end }}
 	`)
 	t := template.Must(template.New("golang-md").Parse(md))
 
@@ -140,10 +172,10 @@ func (r GolangResult) Plaintext() ([]byte, error) {
 
 	plaintext := heredoc.Doc(`
 		Golang
-		------{{ if .Version }}
-		version:  {{ .Version }}{{ end }}{{ if .Goroot }}
-		goroot:   {{ .Goroot }}{{ end }}{{ if .Gopath }}
-		gopath:   {{ .Gopath }}{{ end }}
+		------{{ if and .Version ($.Shown "version") }}
+		{{ printf "%-9s" (print ($.Label "version" "version") ":") }} {{ .Version }}{{ $.Describe "version" }}{{ end }}{{ if and .Goroot ($.Shown "goroot") }}
+		{{ printf "%-9s" (print ($.Label "goroot" "goroot") ":") }} {{ .Goroot }}{{ $.Describe "goroot" }}{{ end }}{{ if and .Gopath ($.Shown "gopath") }}
+		{{ printf "%-9s" (print ($.Label "gopath" "gopath") ":") }} {{ .Gopath }}{{ $.Describe "gopath" }}{{ end }}
 	`)
 
 	t := template.Must(template.New("golang-txt").Parse(plaintext))
@@ -155,6 +187,61 @@ func (r GolangResult) Plaintext() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// GFM renders the GolangResult to GitHub-flavored markdown.
+func (r GolangResult) GFM() ([]byte, error) {
+	log.WithField("src", "golang").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>Golang</summary>
+
+		| Key | Value |
+		| :--- | :--- |{{ if and .Version ($.Shown "version") }}
+		| {{ $.Cell ($.Label "version" "version") }} | {{ $.Cell (print .Version ($.Describe "version")) }} |{{ end }}{{ if and .Goroot ($.Shown "goroot") }}
+		| {{ $.Cell ($.Label "goroot" "goroot") }} | {{ $.Cell (print .Goroot ($.Describe "goroot")) }} |{{ end }}{{ if and .Gopath ($.Shown "gopath") }}
+		| {{ $.Cell ($.Label "gopath" "gopath") }} | {{ $.Cell (print .Gopath ($.Describe "gopath")) }} |{{ end }}
+		
+		</details>
+	`)
+	t := template.Must(template.New("golang-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the GolangResult to an HTML section.
+func (r GolangResult) HTML() ([]byte, error) {
+	log.WithField("src", "golang").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>Golang</summary>
+		<table>{{ if and .Version ($.Shown "version") }}
+		<tr><th>{{ $.Label "version" "version" }}</th><td>{{ .Version }}{{ with $.Description "version" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .Goroot ($.Shown "goroot") }}
+		<tr><th>{{ $.Label "goroot" "goroot" }}</th><td>{{ .Goroot }}{{ with $.Description "goroot" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .Gopath ($.Shown "gopath") }}
+		<tr><th>{{ $.Label "gopath" "gopath" }}</th><td>{{ .Gopath }}{{ with $.Description "gopath" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}
+		</table>
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("golang-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // YAML renders the GolangResult to YAML.
 func (r GolangResult) YAML() ([]byte, error) {
 	log.WithField("src", "golang").Debug("rendering to YAML")
@@ -9,7 +9,7 @@ import (
 
 func TestGolangConfig_Render(t *testing.T) {
 	cfg := GolangConfig{
-		Core: []string{"version"},
+		Core: []Item{{Value: "version"}},
 	}
 
 	out, err := cfg.Render()
@@ -24,7 +24,7 @@ func TestGolangConfig_Render(t *testing.T) {
 
 func TestGolangConfig_Render2(t *testing.T) {
 	cfg := GolangConfig{
-		Core: []string{"goroot"},
+		Core: []Item{{Value: "goroot"}},
 	}
 
 	out, err := cfg.Render()
@@ -39,7 +39,7 @@ func TestGolangConfig_Render2(t *testing.T) {
 
 func TestGolangConfig_Render3(t *testing.T) {
 	cfg := GolangConfig{
-		Core: []string{"gopath"},
+		Core: []Item{{Value: "gopath"}},
 	}
 
 	out, err := cfg.Render()
@@ -54,7 +54,7 @@ func TestGolangConfig_Render3(t *testing.T) {
 
 func TestGolangConfig_Render_Err(t *testing.T) {
 	cfg := GolangConfig{
-		Core: []string{"not-an-option"},
+		Core: []Item{{Value: "not-an-option"}},
 	}
 
 	_, err := cfg.Render()
@@ -136,6 +136,48 @@ func TestGolangResult_Plaintext(t *testing.T) {
 	assert.Equal(t, expected, string(data))
 }
 
+func TestGolangResult_GFM(t *testing.T) {
+	r := NewGolangResult()
+	r.Version = "1.13"
+	r.Gopath = "/go/path"
+	r.addMeta("gopath", Item{Value: "gopath", Description: "workspace"})
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Golang</summary>\n\n| Key | Value |\n| :--- | :--- |\n| version | 1.13 |\n| gopath | /go/path (workspace) |\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestGolangResult_GFM_Empty(t *testing.T) {
+	r := NewGolangResult()
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestGolangResult_HTML(t *testing.T) {
+	r := NewGolangResult()
+	r.Version = "1.13"
+	r.Gopath = "/go/path"
+	r.addMeta("gopath", Item{Value: "gopath", Description: "<workspace>"})
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+
+	expected := "<details class=\"section\" open>\n<summary>Golang</summary>\n<table>\n<tr><th>version</th><td>1.13</td></tr>\n<tr><th>gopath</th><td>/go/path <span class=\"description\">&lt;workspace&gt;</span></td></tr>\n</table>\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestGolangResult_HTML_Empty(t *testing.T) {
+	r := NewGolangResult()
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
 func TestGolangResult_Plaintext_Empty(t *testing.T) {
 	r := NewGolangResult()
 
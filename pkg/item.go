@@ -0,0 +1,213 @@
+package pkg
+
+import (
+	"os"
+	"runtime"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Item is a single entry in a list of config options, such as a command in
+// "exec.run" or an option in "system.core".
+//
+// In the config, an item may either be given as a plain string, or as an
+// object which holds the value along with additional fields, e.g.
+//
+//	run:
+//	- docker --version
+//	- value: sw_vers
+//	  when:
+//	    os: darwin
+//
+// Since version 2 of the config, items may also hold metadata which changes
+// how they are displayed in the rendered output.
+type Item struct {
+	Value string `yaml:"value"`
+
+	// Metadata (config version 2)
+	Label       string `yaml:"label,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Hidden      bool   `yaml:"hidden,omitempty"`
+	Redact      bool   `yaml:"redact,omitempty"`
+
+	When *Condition `yaml:"when,omitempty"`
+}
+
+// itemMetaFields are the config keys of the Item metadata fields.
+var itemMetaFields = []string{"label", "description", "hidden", "redact"}
+
+// The value which redacted item values are replaced with.
+const redactedValue = "[redacted]"
+
+// itemObject is the object form of an Item in the config.
+type itemObject Item
+
+// UnmarshalYAML unmarshals an Item from either its string or object form.
+func (i *Item) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*i = Item{Value: value}
+		return nil
+	}
+
+	var obj itemObject
+	if err := unmarshal(&obj); err != nil {
+		return err
+	}
+	*i = Item(obj)
+	return nil
+}
+
+// MarshalYAML marshals an Item into its string form, if it has no fields
+// other than its value, or into its object form otherwise.
+func (i Item) MarshalYAML() (interface{}, error) {
+	if i.When == nil && !i.hasMeta() {
+		return i.Value, nil
+	}
+	return itemObject(i), nil
+}
+
+// hasMeta checks whether the item has any metadata set.
+func (i Item) hasMeta() bool {
+	return i.Label != "" || i.Description != "" || i.Hidden || i.Redact
+}
+
+// meta gets the metadata of the item which is used to display it.
+func (i Item) meta() ItemMeta {
+	return ItemMeta{
+		Label:       i.Label,
+		Description: i.Description,
+		Hidden:      i.Hidden,
+		Redact:      i.Redact,
+	}
+}
+
+// redact gets the value to render for the item: the collected value itself,
+// or a placeholder if the item is redacted. Values which were not collected
+// are left empty, so it is still clear that they are missing.
+func (i Item) redact(value string) string {
+	if i.Redact && value != "" {
+		return redactedValue
+	}
+	return value
+}
+
+// Items is a list of config option items.
+type Items []Item
+
+// withMeta gets the first item in the list which has metadata set, if any.
+func (i Items) withMeta() (Item, bool) {
+	for _, item := range i {
+		if item.hasMeta() {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// Values gets the values of all of the items.
+func (i Items) Values() []string {
+	var values []string
+	for _, item := range i {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+// Contains checks whether an item with the given value exists in the list.
+func (i Items) Contains(value string) bool {
+	for _, item := range i {
+		if item.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Append an item with the given value to the list if the list does not
+// already contain an item with that value.
+func (i Items) Append(value string) Items {
+	if i.Contains(value) {
+		return i
+	}
+	return append(i, Item{Value: value})
+}
+
+// Condition defines the circumstances in which a config section or item
+// applies. Sections and items which do not apply are skipped when rendering.
+//
+// For a condition to apply, every one of its specified fields must match.
+// The "os" and "arch" fields match if any of the listed values matches the
+// current platform, while the "env" and "bin" fields match only if all of the
+// listed environment variables are set and binaries exist on the PATH.
+type Condition struct {
+	OS   stringList `yaml:"os,omitempty"`
+	Arch stringList `yaml:"arch,omitempty"`
+	Env  stringList `yaml:"env,omitempty"`
+	Bin  stringList `yaml:"bin,omitempty"`
+}
+
+// Applies checks whether the condition applies to the current environment.
+// A nil condition always applies.
+func (c *Condition) Applies() bool {
+	if c == nil {
+		return true
+	}
+	if len(c.OS) != 0 && !c.OS.Contains(runtime.GOOS) {
+		return false
+	}
+	if len(c.Arch) != 0 && !c.Arch.Contains(runtime.GOARCH) {
+		return false
+	}
+	for _, key := range c.Env {
+		if _, ok := os.LookupEnv(key); !ok {
+			return false
+		}
+	}
+	for _, bin := range c.Bin {
+		if !binExists(bin) {
+			return false
+		}
+	}
+	return true
+}
+
+// applies checks whether an item applies to the current environment, logging
+// any item which is skipped.
+func (i Item) applies(l *log.Entry) bool {
+	if i.When.Applies() {
+		return true
+	}
+	l.WithField("item", i.Value).Debug("skipping item: condition does not apply")
+	return false
+}
+
+// stringList is a list of strings which may be given in the config either as
+// a list or as a single string.
+type stringList []string
+
+// UnmarshalYAML unmarshals a stringList from either a string or a list.
+func (s *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*s = stringList{value}
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// Contains checks whether the list contains the given string.
+func (s stringList) Contains(value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
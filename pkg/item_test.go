@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestItem_UnmarshalYAML(t *testing.T) {
+	data := heredoc.Doc(`
+		- foo
+		- value: bar
+		- value: baz
+		  when:
+		    os: darwin
+		    bin: [brew, git]
+	`)
+
+	var items Items
+	err := yaml.UnmarshalStrict([]byte(data), &items)
+	assert.NoError(t, err)
+	assert.Equal(t, Items{
+		{Value: "foo"},
+		{Value: "bar"},
+		{Value: "baz", When: &Condition{OS: stringList{"darwin"}, Bin: stringList{"brew", "git"}}},
+	}, items)
+}
+
+func TestItem_UnmarshalYAML_Err(t *testing.T) {
+	var items Items
+	err := yaml.UnmarshalStrict([]byte("- valu: foo\n"), &items)
+	assert.Error(t, err)
+
+	err = yaml.UnmarshalStrict([]byte("- [foo]\n"), &items)
+	assert.Error(t, err)
+}
+
+func TestItem_MarshalYAML(t *testing.T) {
+	items := Items{
+		{Value: "foo"},
+		{Value: "bar", When: &Condition{OS: stringList{"linux"}}},
+	}
+
+	data, err := yaml.Marshal(items)
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		- foo
+		- value: bar
+		  when:
+		    os:
+		    - linux
+	`), string(data))
+}
+
+func TestItem_MarshalYAML_Meta(t *testing.T) {
+	items := Items{
+		{Value: "docker --version", Label: "Docker engine", Hidden: true},
+	}
+
+	data, err := yaml.Marshal(items)
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		- value: docker --version
+		  label: Docker engine
+		  hidden: true
+	`), string(data))
+}
+
+func TestItem_UnmarshalYAML_Meta(t *testing.T) {
+	data := heredoc.Doc(`
+		- value: TOKEN
+		  label: API token
+		  description: used for deploys
+		  redact: true
+	`)
+
+	var items Items
+	err := yaml.UnmarshalStrict([]byte(data), &items)
+	assert.NoError(t, err)
+	assert.Equal(t, Items{
+		{Value: "TOKEN", Label: "API token", Description: "used for deploys", Redact: true},
+	}, items)
+}
+
+func TestItem_redact(t *testing.T) {
+	assert.Equal(t, "secret", Item{Value: "A"}.redact("secret"))
+	assert.Equal(t, redactedValue, Item{Value: "A", Redact: true}.redact("secret"))
+	assert.Equal(t, "", Item{Value: "A", Redact: true}.redact(""))
+}
+
+func TestItems_withMeta(t *testing.T) {
+	_, ok := Items{{Value: "a"}, {Value: "b"}}.withMeta()
+	assert.False(t, ok)
+
+	item, ok := Items{{Value: "a"}, {Value: "b", Description: "desc"}}.withMeta()
+	assert.True(t, ok)
+	assert.Equal(t, "b", item.Value)
+}
+
+func TestItems_Values(t *testing.T) {
+	assert.Nil(t, Items{}.Values())
+	assert.Equal(t, []string{"a", "b"}, Items{{Value: "a"}, {Value: "b"}}.Values())
+}
+
+func TestItems_Contains(t *testing.T) {
+	items := Items{{Value: "a"}}
+	assert.True(t, items.Contains("a"))
+	assert.False(t, items.Contains("b"))
+}
+
+func TestItems_Append(t *testing.T) {
+	assert.Equal(t, Items{{Value: "a"}, {Value: "b"}}, Items{{Value: "a"}}.Append("b"))
+	assert.Equal(t, Items{{Value: "a"}, {Value: "b"}}, Items{{Value: "a"}, {Value: "b"}}.Append("a"))
+}
+
+func TestCondition_Applies(t *testing.T) {
+	assert.NoError(t, os.Setenv("ENVSNAP_TEST_VAR", ""))
+	defer os.Unsetenv("ENVSNAP_TEST_VAR")
+
+	var tests = []struct {
+		name    string
+		cond    *Condition
+		applies bool
+	}{
+		{"nil", nil, true},
+		{"empty", &Condition{}, true},
+		{"os match", &Condition{OS: stringList{"plan9", runtime.GOOS}}, true},
+		{"os no match", &Condition{OS: stringList{"plan9"}}, false},
+		{"arch match", &Condition{Arch: stringList{runtime.GOARCH}}, true},
+		{"arch no match", &Condition{Arch: stringList{"not-an-arch"}}, false},
+		{"env set", &Condition{Env: stringList{"ENVSNAP_TEST_VAR"}}, true},
+		{"env not set", &Condition{Env: stringList{"ENVSNAP_TEST_VAR", "ENVSNAP_NOT_SET"}}, false},
+		{"bin exists", &Condition{Bin: stringList{"go"}}, true},
+		{"bin not exists", &Condition{Bin: stringList{"go", "jk3rlkdal3r93"}}, false},
+		{"all match", &Condition{OS: stringList{runtime.GOOS}, Bin: stringList{"go"}}, true},
+		{"partial match", &Condition{OS: stringList{runtime.GOOS}, Bin: stringList{"jk3rlkdal3r93"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.applies, tt.cond.Applies())
+		})
+	}
+}
+
+func TestStringList_UnmarshalYAML(t *testing.T) {
+	var c Condition
+	err := yaml.UnmarshalStrict([]byte("os: linux\narch: [amd64, arm64]\n"), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, stringList{"linux"}, c.OS)
+	assert.Equal(t, stringList{"amd64", "arm64"}, c.Arch)
+
+	err = yaml.UnmarshalStrict([]byte("os: {foo: bar}\n"), &c)
+	assert.Error(t, err)
+}
+
+func TestStringList_Contains(t *testing.T) {
+	assert.True(t, stringList{"a", "b"}.Contains("b"))
+	assert.False(t, stringList{"a", "b"}.Contains("c"))
+}
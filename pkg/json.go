@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// JSONOptions configures how snapshots are rendered to JSON.
+type JSONOptions struct {
+	// Indent is the number of spaces to indent nested values by. If it is
+	// 0, the JSON is rendered compactly on a single line.
+	Indent int
+
+	// Canonical renders the JSON in a canonical form, so that two renders
+	// of the same environment are byte-identical (see canonicalJSON).
+	Canonical bool
+}
+
+// jsonOptions are the options for rendering snapshots to JSON.
+var jsonOptions JSONOptions
+
+// volatileMetaFields are the fields of the snapshot metadata which differ
+// between any two renders, and so are left out of canonical JSON.
+var volatileMetaFields = []string{"captured_at", "duration"}
+
+// marshalJSON renders the value to JSON with the given options.
+func marshalJSON(v interface{}, opts JSONOptions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Canonical {
+		if data, err = canonicalJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Indent > 0 {
+		buffer := bytes.Buffer{}
+		if err := json.Indent(&buffer, data, "", strings.Repeat(" ", opts.Indent)); err != nil {
+			return nil, err
+		}
+		data = buffer.Bytes()
+	}
+	return data, nil
+}
+
+// canonicalJSON converts a JSON snapshot to its canonical form:
+//
+//   - the keys of every object, including those of the snapshot and its
+//     sections, are sorted
+//   - line endings in exec output are normalized to "\n"
+//   - the volatile fields of the snapshot metadata are removed, along with
+//     the metadata itself if nothing else is left in it
+func canonicalJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var snapshot map[string]interface{}
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, err
+	}
+
+	if meta, ok := snapshot["meta"].(map[string]interface{}); ok {
+		for _, field := range volatileMetaFields {
+			delete(meta, field)
+		}
+		if len(meta) == 0 {
+			delete(snapshot, "meta")
+		}
+	}
+
+	if exec, ok := snapshot["exec"]; ok {
+		snapshot["exec"] = normalizeJSONNewlines(exec)
+	}
+
+	// Objects are decoded into maps, which are encoded with sorted keys.
+	return json.Marshal(snapshot)
+}
+
+// normalizeJSONNewlines normalizes the line endings of all of the strings in
+// a decoded JSON value.
+func normalizeJSONNewlines(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return normalizeNewlines(v)
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = normalizeJSONNewlines(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeJSONNewlines(val)
+		}
+	}
+	return value
+}
+
+// normalizeNewlines converts Windows ("\r\n") and classic Mac ("\r") line
+// endings to "\n".
+func normalizeNewlines(s string) string {
+	return strings.Replace(strings.Replace(s, "\r\n", "\n", -1), "\r", "\n", -1)
+}
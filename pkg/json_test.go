@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+// jsonTestResult creates a result with volatile metadata and exec output
+// with mixed line endings.
+func jsonTestResult(captured time.Time) *V1EnvsnapResult {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+	sys.CPUs = 4
+
+	exec := NewExecResult()
+	exec.Exec["echo"] = "a\r\nb\rc\n"
+
+	res := NewV1EnvsnapResult()
+	res.Meta = &SnapshotMeta{CapturedAt: captured, Duration: "12ms", Hostname: "host"}
+	res.System = sys
+	res.Exec = exec
+	return &res
+}
+
+func TestMarshalJSON(t *testing.T) {
+	res := jsonTestResult(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	data, err := marshalJSON(res, JSONOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"meta":{"captured_at":"2020-01-02T03:04:05Z","duration":"12ms","hostname":"host"},"exec":{"exec":{"echo":"a\r\nb\rc\n"}},"system":{"os":"linux","cpus":4}}`, string(data))
+}
+
+func TestMarshalJSON_Indent(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+
+	res := NewV1EnvsnapResult()
+	res.System = sys
+
+	data, err := marshalJSON(res, JSONOptions{Indent: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		{
+		  "version": 1,
+		  "system": {
+		    "os": "linux"
+		  }
+		}`), string(data))
+
+	data, err = marshalJSON(res, JSONOptions{Indent: 4})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\n        \"os\": \"linux\"\n")
+}
+
+func TestMarshalJSON_Canonical(t *testing.T) {
+	res := jsonTestResult(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	data, err := marshalJSON(res, JSONOptions{Canonical: true})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"exec":{"exec":{"echo":"a\nb\nc\n"}},"meta":{"hostname":"host"},"system":{"cpus":4,"os":"linux"},"version":1}`, string(data))
+
+	// Renders at different times are identical.
+	other, err := marshalJSON(jsonTestResult(time.Now()), JSONOptions{Canonical: true})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(other))
+}
+
+func TestMarshalJSON_CanonicalIndent(t *testing.T) {
+	res := jsonTestResult(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	res.Meta.Hostname = ""
+
+	data, err := marshalJSON(res, JSONOptions{Indent: 2, Canonical: true})
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		{
+		  "exec": {
+		    "exec": {
+		      "echo": "a\nb\nc\n"
+		    }
+		  },
+		  "system": {
+		    "cpus": 4,
+		    "os": "linux"
+		  },
+		  "version": 1
+		}`), string(data))
+}
+
+func TestNormalizeJSONNewlines(t *testing.T) {
+	value := map[string]interface{}{"a": "x\r\n", "b": []interface{}{"y\r", 1}}
+	assert.Equal(t, map[string]interface{}{"a": "x\n", "b": []interface{}{"y\n", 1}}, normalizeJSONNewlines(value))
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	assert.Equal(t, "a\nb\nc\n\nd", normalizeNewlines("a\r\nb\rc\n\r\nd"))
+}
+
+func TestV1EnvsnapResult_String_JSONOptions(t *testing.T) {
+	defer func() { jsonOptions = JSONOptions{} }()
+	jsonOptions = JSONOptions{Indent: 2, Canonical: true}
+
+	res := NewV1EnvsnapResult()
+	data, err := res.String("json")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"version\": 1\n}", data)
+}
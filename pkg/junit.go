@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a group of test cases in a JUnit XML report.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a single test case in a JUnit XML report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes why a test case in a JUnit XML report failed.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// checkKind gets the kind of check which produced the result, as named in
+// the 'checks' section of the config.
+func checkKind(res CheckResult) string {
+	switch res.Source {
+	case "exec.run":
+		return "exec"
+	case "environment.variables":
+		return "environment"
+	default:
+		return "versions"
+	}
+}
+
+// checkWarnings gets the warnings produced during render which relate to the
+// data point the check was evaluated against, sorted. Warnings which name a
+// specific item (e.g. a command or package) in quotes are only included for
+// the check of that item.
+func checkWarnings(w *Warnings, res CheckResult) []string {
+	sources := []string{res.Source}
+	item := res.Item
+	switch {
+	case strings.HasPrefix(res.Source, "python.dependencies."):
+		sources = []string{"python.dependencies.packages", "python.deps.packages"}
+		item = strings.TrimPrefix(res.Source, "python.dependencies.")
+	case strings.HasPrefix(res.Source, "system.core."):
+		sources = []string{"system.core"}
+	}
+
+	var warnings []string
+	for _, src := range sources {
+		for _, msg := range w.Warnings[src] {
+			if item != "" && strings.Contains(msg, "'") && !strings.Contains(msg, "'"+item+"'") {
+				continue
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// junitCase converts a check result into a JUnit test case. The message of
+// a failure is taken from the warnings related to the check, if there are
+// any, as they explain why a value could not be collected. Otherwise, it is
+// the message of the check result, or a description of the failed constraint.
+func junitCase(res CheckResult, w *Warnings) junitTestCase {
+	tc := junitTestCase{
+		Name:      res.Name(),
+		Classname: "checks." + checkKind(res),
+	}
+	if res.Passed {
+		return tc
+	}
+
+	warnings := checkWarnings(w, res)
+	message := res.Message
+	if len(warnings) != 0 {
+		message = strings.Join(warnings, "; ")
+	} else if message == "" {
+		message = fmt.Sprintf("'%s' does not satisfy %s", res.Actual, res.Constraint)
+	}
+
+	details := []string{
+		"constraint: " + res.Constraint,
+		"actual: " + res.Actual,
+	}
+	if res.Message != "" {
+		details = append(details, "message: "+res.Message)
+	}
+	for _, warning := range warnings {
+		details = append(details, "warning: "+warning)
+	}
+
+	tc.Failure = &junitFailure{
+		Message: message,
+		Type:    checkKind(res),
+		Text:    strings.Join(details, "\n"),
+	}
+	return tc
+}
+
+// JUnit renders the check results as a JUnit XML report, for CI systems
+// which display test results. Each check is a test case, grouped into a
+// test suite for each kind of check (versions, exec and environment). The
+// warnings produced during render are used to explain failed checks (see
+// junitCase).
+func (r CheckResults) JUnit(w *Warnings) ([]byte, error) {
+	report := junitTestSuites{
+		Name:     "envsnap check",
+		Tests:    len(r),
+		Failures: r.Failed(),
+	}
+
+	for _, kind := range []string{"versions", "exec", "environment"} {
+		suite := junitTestSuite{Name: "checks." + kind}
+		for _, res := range r {
+			if checkKind(res) != kind {
+				continue
+			}
+			suite.Tests++
+			if !res.Passed {
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, junitCase(res, w))
+		}
+		if suite.Tests != 0 {
+			report.Suites = append(report.Suites, suite)
+		}
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
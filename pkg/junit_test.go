@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckKind(t *testing.T) {
+	assert.Equal(t, "versions", checkKind(CheckResult{Source: "go.core.version"}))
+	assert.Equal(t, "exec", checkKind(CheckResult{Source: "exec.run", Item: "ls"}))
+	assert.Equal(t, "environment", checkKind(CheckResult{Source: "environment.variables", Item: "HOME"}))
+}
+
+func TestCheckWarnings(t *testing.T) {
+	w := NewWarnings()
+	w.Add("go.core.version", "go executable not found")
+	w.Add("exec.run", "error while running command: '%s'", "false")
+	w.Add("exec.run", "error while running command: '%s'", "true")
+	w.Add("python.dependencies.packages", "python dependency not found: '%s'", "foo")
+	w.Add("python.deps.packages", "pip executable not found")
+	w.Add("system.core", "error collecting system info")
+
+	tests := []struct {
+		res      CheckResult
+		expected []string
+	}{
+		{CheckResult{Source: "go.core.version"}, []string{"go executable not found"}},
+		{CheckResult{Source: "python.core.version"}, nil},
+		{CheckResult{Source: "exec.run", Item: "false"}, []string{"error while running command: 'false'"}},
+		{CheckResult{Source: "exec.run", Item: "echo"}, nil},
+		{CheckResult{Source: "python.dependencies.foo"}, []string{"pip executable not found", "python dependency not found: 'foo'"}},
+		{CheckResult{Source: "python.dependencies.bar"}, []string{"pip executable not found"}},
+		{CheckResult{Source: "system.core.kernel_version"}, []string{"error collecting system info"}},
+		{CheckResult{Source: "environment.variables", Item: "HOME"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.res.Name(), func(t *testing.T) {
+			assert.Equal(t, test.expected, checkWarnings(w, test.res))
+		})
+	}
+}
+
+func TestJunitCase(t *testing.T) {
+	w := NewWarnings()
+	w.Add("go.core.version", "go executable not found")
+
+	tests := []struct {
+		name     string
+		res      CheckResult
+		expected *junitFailure
+	}{
+		{
+			name: "passed",
+			res:  CheckResult{Source: "exec.run", Item: "echo", Constraint: "/hi/", Actual: "hi", Passed: true},
+		},
+		{
+			name: "warning",
+			res:  CheckResult{Source: "go.core.version", Constraint: ">=1.13", Message: "no version collected"},
+			expected: &junitFailure{
+				Message: "go executable not found",
+				Type:    "versions",
+				Text:    "constraint: >=1.13\nactual: \nmessage: no version collected\nwarning: go executable not found",
+			},
+		},
+		{
+			name: "message",
+			res:  CheckResult{Source: "environment.variables", Item: "FOO", Constraint: "set", Message: "variable is not set"},
+			expected: &junitFailure{
+				Message: "variable is not set",
+				Type:    "environment",
+				Text:    "constraint: set\nactual: \nmessage: variable is not set",
+			},
+		},
+		{
+			name: "constraint",
+			res:  CheckResult{Source: "python.core.version", Constraint: ">=3.8", Actual: "3.7.0"},
+			expected: &junitFailure{
+				Message: "'3.7.0' does not satisfy >=3.8",
+				Type:    "versions",
+				Text:    "constraint: >=3.8\nactual: 3.7.0",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := junitCase(test.res, w)
+			assert.Equal(t, test.res.Name(), tc.Name)
+			assert.Equal(t, "checks."+checkKind(test.res), tc.Classname)
+			assert.Equal(t, test.expected, tc.Failure)
+		})
+	}
+}
+
+func TestCheckResults_JUnit(t *testing.T) {
+	w := NewWarnings()
+	w.Add("exec.run", "error while running command: '%s'", "false")
+
+	results := CheckResults{
+		{Source: "go.core.version", Constraint: ">=1.13", Actual: "1.13.4", Passed: true},
+		{Source: "exec.run", Item: "false", Constraint: "/ok/", Message: "no output collected"},
+	}
+
+	data, err := results.JUnit(w)
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		<?xml version="1.0" encoding="UTF-8"?>
+		<testsuites name="envsnap check" tests="2" failures="1">
+		  <testsuite name="checks.versions" tests="1" failures="0" errors="0">
+		    <testcase name="go.core.version" classname="checks.versions"></testcase>
+		  </testsuite>
+		  <testsuite name="checks.exec" tests="1" failures="1" errors="0">
+		    <testcase name="exec.run[false]" classname="checks.exec">
+		      <failure message="error while running command: &#39;false&#39;" type="exec"><![CDATA[constraint: /ok/
+		actual: 
+		message: no output collected
+		warning: error while running command: 'false']]></failure>
+		    </testcase>
+		  </testsuite>
+		</testsuites>
+	`), string(data))
+}
+
+func TestCheckResults_JUnit_Empty(t *testing.T) {
+	data, err := CheckResults{}.JUnit(NewWarnings())
+	assert.NoError(t, err)
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<testsuites name=\"envsnap check\" tests=\"0\" failures=\"0\"></testsuites>\n", string(data))
+}
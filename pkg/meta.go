@@ -0,0 +1,289 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// MetaOptions configures the metadata which is recorded in snapshots.
+type MetaOptions struct {
+	// Enabled sets whether snapshots record metadata at all.
+	Enabled bool
+
+	// AnonymizeHostname replaces the hostname with an identifier derived
+	// from it, so snapshots from the same host can be matched up without
+	// revealing its name.
+	AnonymizeHostname bool
+}
+
+// metaOptions are the options for the metadata of rendered snapshots.
+var metaOptions MetaOptions
+
+// SnapshotMeta describes how and where a snapshot was captured.
+type SnapshotMeta struct {
+	// Common
+	ResultCommon `json:"-" yaml:"-"`
+
+	CapturedAt time.Time `json:"captured_at" yaml:"captured_at"`
+	Duration   string    `json:"duration,omitempty" yaml:"duration,omitempty"`
+
+	// The version of envsnap which captured the snapshot.
+	Version string `json:"envsnap_version,omitempty" yaml:"envsnap_version,omitempty"`
+	Commit  string `json:"envsnap_commit,omitempty" yaml:"envsnap_commit,omitempty"`
+
+	// The config which the snapshot was rendered from. The digest is taken
+	// of the resolved config, so it changes when any config it extends does.
+	Config       string `json:"config,omitempty" yaml:"config,omitempty"`
+	ConfigDigest string `json:"config_digest,omitempty" yaml:"config_digest,omitempty"`
+
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+}
+
+// newSnapshotMeta creates the metadata for a snapshot which was captured
+// from the given start time until now, from the config with the given path
+// and resolved data.
+func newSnapshotMeta(start time.Time, config string, data []byte, opts MetaOptions) *SnapshotMeta {
+	meta := &SnapshotMeta{
+		ResultCommon: common,
+		CapturedAt:   start.UTC().Truncate(time.Second),
+		Duration:     time.Since(start).Round(time.Millisecond).String(),
+		Version:      Version,
+		Commit:       Commit,
+		Config:       config,
+	}
+	if data != nil {
+		meta.ConfigDigest = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.WithField("error", err).Debug("failed to get hostname")
+	} else if opts.AnonymizeHostname {
+		key, err := loadHostKey("")
+		if err != nil {
+			// The hostname is left out rather than recorded in the clear.
+			cliWarnings.Add("meta.hostname", "hostname left out: failed to load the key to anonymize it with: %v", err)
+		} else {
+			meta.Hostname = anonymizeHostname(key, hostname)
+		}
+	} else {
+		meta.Hostname = hostname
+	}
+	return meta
+}
+
+// The name of the file which holds the secret key that hostnames are
+// anonymized with. It is kept alongside the trust store.
+const hostKeyFile = "hostname.key"
+
+// hostKeySize is the size, in bytes, of the key hostnames are anonymized with.
+const hostKeySize = 32
+
+// loadHostKey loads the secret key which hostnames are anonymized with from
+// the given path, or from the directory of the trust store if the path is
+// empty. If there is no key yet, a random key is created and saved, readable
+// only by the user.
+func loadHostKey(path string) ([]byte, error) {
+	if path == "" {
+		store, err := (&TrustStore{}).path()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(filepath.Dir(store), hostKeyFile)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != hostKeySize {
+			return nil, fmt.Errorf("%s: invalid key", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, hostKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// anonymizeHostname derives an identifier from the hostname which does not
+// reveal it. The identifier is keyed with a secret which is unique to the
+// user, so that it can not be reversed by hashing likely hostnames. The same
+// hostname and key always give the same identifier.
+func anonymizeHostname(key []byte, hostname string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hostname))
+	return fmt.Sprintf("host-%x", mac.Sum(nil))[:17]
+}
+
+// IsEmpty checks whether the result contains any data.
+func (r SnapshotMeta) IsEmpty() bool {
+	return r.CapturedAt.IsZero()
+}
+
+// Captured gets the capture time formatted for display.
+func (r SnapshotMeta) Captured() string {
+	return r.CapturedAt.Format(time.RFC3339)
+}
+
+// Markdown renders the SnapshotMeta to markdown.
+func (r SnapshotMeta) Markdown() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	md := heredoc.Doc(`
+		**Snapshot**
+		- _captured at_: {{ .Captured }}{{ if .Duration }}
+		- _duration_: {{ .Duration }}{{ end }}{{ if .Version }}
+		- _envsnap_: {{ .Version }}{{ with .Commit }} ({{ . }}){{ end }}{{ end }}{{ if .Config }}
+		- _config_: {{ .Config }}{{ end }}{{ if .ConfigDigest }}
+		- _config digest_: {{ .ConfigDigest }}{{ end }}{{ if .Hostname }}
+		- _hostname_: {{ .Hostname }}{{ end }}
+	`)
+	t := template.Must(template.New("meta-md").Parse(md))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Plaintext renders the SnapshotMeta to plaintext.
+func (r SnapshotMeta) Plaintext() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to plaintext")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	plaintext := heredoc.Doc(`
+		Snapshot
+		--------
+		captured at:   {{ .Captured }}{{ if .Duration }}
+		duration:      {{ .Duration }}{{ end }}{{ if .Version }}
+		envsnap:       {{ .Version }}{{ with .Commit }} ({{ . }}){{ end }}{{ end }}{{ if .Config }}
+		config:        {{ .Config }}{{ end }}{{ if .ConfigDigest }}
+		config digest: {{ .ConfigDigest }}{{ end }}{{ if .Hostname }}
+		hostname:      {{ .Hostname }}{{ end }}
+	`)
+
+	t := template.Must(template.New("meta-txt").Parse(plaintext))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// GFM renders the SnapshotMeta to GitHub-flavored markdown.
+func (r SnapshotMeta) GFM() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>Snapshot</summary>
+
+		| Key | Value |
+		| :--- | :--- |
+		| captured at | {{ .Captured }} |{{ if .Duration }}
+		| duration | {{ .Duration }} |{{ end }}{{ if .Version }}
+		| envsnap | {{ $.Cell .Version }}{{ with .Commit }} ({{ $.Cell . }}){{ end }} |{{ end }}{{ if .Config }}
+		| config | {{ $.Cell .Config }} |{{ end }}{{ if .ConfigDigest }}
+		| config digest | {{ .ConfigDigest }} |{{ end }}{{ if .Hostname }}
+		| hostname | {{ $.Cell .Hostname }} |{{ end }}
+
+		</details>
+	`)
+	t := template.Must(template.New("meta-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the SnapshotMeta to an HTML section.
+func (r SnapshotMeta) HTML() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>Snapshot</summary>
+		<table>
+		<tr><th>captured at</th><td>{{ .Captured }}</td></tr>{{ if .Duration }}
+		<tr><th>duration</th><td>{{ .Duration }}</td></tr>{{ end }}{{ if .Version }}
+		<tr><th>envsnap</th><td>{{ .Version }}{{ with .Commit }} ({{ . }}){{ end }}</td></tr>{{ end }}{{ if .Config }}
+		<tr><th>config</th><td>{{ .Config }}</td></tr>{{ end }}{{ if .ConfigDigest }}
+		<tr><th>config digest</th><td><code>{{ .ConfigDigest }}</code></td></tr>{{ end }}{{ if .Hostname }}
+		<tr><th>hostname</th><td>{{ .Hostname }}</td></tr>{{ end }}
+		</table>
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("meta-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// YAML renders the SnapshotMeta to YAML.
+func (r SnapshotMeta) YAML() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to YAML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+	return yaml.Marshal(&r)
+}
+
+// JSON renders the SnapshotMeta to JSON.
+func (r SnapshotMeta) JSON() ([]byte, error) {
+	log.WithField("src", "meta").Debug("rendering to JSON")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+	return json.Marshal(&r)
+}
@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSnapshotMeta creates snapshot metadata with every field set.
+func testSnapshotMeta() SnapshotMeta {
+	return SnapshotMeta{
+		CapturedAt:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:     "1.5s",
+		Version:      "1.2.3",
+		Commit:       "abc123",
+		Config:       ".envsnap",
+		ConfigDigest: "sha256:def456",
+		Hostname:     "host-a|b",
+	}
+}
+
+func TestNewSnapshotMeta(t *testing.T) {
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+
+	start := time.Now().Add(-1500 * time.Millisecond)
+	meta := newSnapshotMeta(start, ".envsnap", []byte("version: 1\n"), MetaOptions{Enabled: true})
+
+	assert.Equal(t, start.UTC().Truncate(time.Second), meta.CapturedAt)
+	assert.Equal(t, time.UTC, meta.CapturedAt.Location())
+	assert.True(t, strings.HasPrefix(meta.Duration, "1.5"), meta.Duration)
+	assert.Equal(t, Version, meta.Version)
+	assert.Equal(t, Commit, meta.Commit)
+	assert.Equal(t, ".envsnap", meta.Config)
+	assert.Equal(t, "sha256:09bfcc6a14b83e2192b8673677725c84883ee9cd0c70e45c9ec09daa8f2b2847", meta.ConfigDigest)
+	assert.Equal(t, hostname, meta.Hostname)
+}
+
+func TestNewSnapshotMeta_Anonymize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(trustStoreEnv, filepath.Join(dir, "trusted.json"))
+	defer os.Unsetenv(trustStoreEnv)
+
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+
+	meta := newSnapshotMeta(time.Now(), "", nil, MetaOptions{Enabled: true, AnonymizeHostname: true})
+	assert.Empty(t, meta.ConfigDigest)
+
+	// The key should be created alongside the trust store.
+	key, err := loadHostKey(filepath.Join(dir, hostKeyFile))
+	assert.NoError(t, err)
+	assert.Equal(t, anonymizeHostname(key, hostname), meta.Hostname)
+}
+
+func TestNewSnapshotMeta_AnonymizeErr(t *testing.T) {
+	defer cliWarnings.Clear()
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(trustStoreEnv, filepath.Join(dir, "trusted.json"))
+	defer os.Unsetenv(trustStoreEnv)
+
+	// The key can not be read if its path is a directory.
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, hostKeyFile), 0700))
+
+	meta := newSnapshotMeta(time.Now(), "", nil, MetaOptions{Enabled: true, AnonymizeHostname: true})
+	assert.Empty(t, meta.Hostname)
+	assert.Len(t, cliWarnings.Warnings["meta.hostname"], 1)
+	assert.Contains(t, cliWarnings.Warnings["meta.hostname"][0], "hostname left out: failed to load the key to anonymize it with")
+}
+
+func TestLoadHostKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "envsnap", hostKeyFile)
+	key, err := loadHostKey(path)
+	assert.NoError(t, err)
+	assert.Len(t, key, hostKeySize)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The same key should be loaded again.
+	loaded, err := loadHostKey(path)
+	assert.NoError(t, err)
+	assert.Equal(t, key, loaded)
+
+	// A different path gets a different random key.
+	other, err := loadHostKey(filepath.Join(dir, "other.key"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestLoadHostKey_Invalid(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("not a key\n")
+	assert.NoError(t, err)
+
+	_, err = loadHostKey(file.Name())
+	assert.EqualError(t, err, file.Name()+": invalid key")
+}
+
+func TestAnonymizeHostname(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	id := anonymizeHostname(key, "build-01.example.com")
+	assert.Len(t, id, 17)
+	assert.True(t, strings.HasPrefix(id, "host-"))
+	assert.NotContains(t, id, "build")
+	assert.Equal(t, id, anonymizeHostname(key, "build-01.example.com"))
+	assert.NotEqual(t, id, anonymizeHostname(key, "build-02.example.com"))
+
+	// The identifier depends on the key, so it can not be reversed without it.
+	assert.NotEqual(t, id, anonymizeHostname([]byte("fedcba9876543210fedcba9876543210"), "build-01.example.com"))
+}
+
+func TestSnapshotMeta_IsEmpty(t *testing.T) {
+	assert.True(t, SnapshotMeta{}.IsEmpty())
+	assert.False(t, testSnapshotMeta().IsEmpty())
+}
+
+func TestSnapshotMeta_Markdown(t *testing.T) {
+	data, err := testSnapshotMeta().Markdown()
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		**Snapshot**
+		- _captured at_: 2020-01-02T03:04:05Z
+		- _duration_: 1.5s
+		- _envsnap_: 1.2.3 (abc123)
+		- _config_: .envsnap
+		- _config digest_: sha256:def456
+		- _hostname_: host-a|b
+	`), string(data))
+}
+
+func TestSnapshotMeta_Plaintext(t *testing.T) {
+	data, err := testSnapshotMeta().Plaintext()
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		Snapshot
+		--------
+		captured at:   2020-01-02T03:04:05Z
+		duration:      1.5s
+		envsnap:       1.2.3 (abc123)
+		config:        .envsnap
+		config digest: sha256:def456
+		hostname:      host-a|b
+	`), string(data))
+}
+
+func TestSnapshotMeta_GFM(t *testing.T) {
+	data, err := testSnapshotMeta().GFM()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<details><summary>Snapshot</summary>\n")
+	assert.Contains(t, string(data), "| envsnap | 1.2.3 (abc123) |\n")
+	assert.Contains(t, string(data), "| hostname | host-a\\|b |\n")
+}
+
+func TestSnapshotMeta_HTML(t *testing.T) {
+	data, err := testSnapshotMeta().HTML()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<summary>Snapshot</summary>\n<table>\n<tr><th>captured at</th><td>2020-01-02T03:04:05Z</td></tr>")
+	assert.Contains(t, string(data), "<tr><th>config digest</th><td><code>sha256:def456</code></td></tr>")
+}
+
+func TestSnapshotMeta_JSON(t *testing.T) {
+	data, err := testSnapshotMeta().JSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"captured_at":"2020-01-02T03:04:05Z","duration":"1.5s","envsnap_version":"1.2.3","envsnap_commit":"abc123","config":".envsnap","config_digest":"sha256:def456","hostname":"host-a|b"}`, string(data))
+}
+
+func TestSnapshotMeta_YAML(t *testing.T) {
+	data, err := SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Version: "1.2.3"}.YAML()
+	assert.NoError(t, err)
+	assert.Equal(t, "captured_at: 2020-01-02T03:04:05Z\nenvsnap_version: 1.2.3\n", string(data))
+}
+
+func TestSnapshotMeta_Empty(t *testing.T) {
+	meta := SnapshotMeta{}
+	for _, render := range []func() ([]byte, error){meta.Markdown, meta.Plaintext, meta.GFM, meta.HTML, meta.JSON, meta.YAML} {
+		data, err := render()
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	}
+}
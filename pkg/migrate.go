@@ -0,0 +1,260 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// latestConfigVersion is the newest version of the envsnap configuration
+// scheme, which configs are migrated to.
+var latestConfigVersion = configV2
+
+// configMigration transforms the root mapping of a config document from one
+// version of the configuration scheme to the next. The version of the config
+// is updated separately, so migrations only need to transform its options.
+type configMigration func(root *yamlv3.Node) error
+
+// configMigrations maps each config version to the migration which upgrades
+// a config of that version to the following version.
+var configMigrations = map[int]configMigration{
+	configV1: migrateV1,
+}
+
+// migrateV1 migrates a version 1 config to version 2. Version 2 only adds
+// metadata to config items, so every version 1 config is also a valid
+// version 2 config.
+func migrateV1(root *yamlv3.Node) error {
+	return nil
+}
+
+// MigrateConfig migrates the raw configuration data to the latest version of
+// the configuration scheme. It returns the migrated data along with the
+// version the data was migrated from.
+//
+// The config is transformed as a YAML node tree, but only the text of the
+// parts of the config which change is rewritten (see spliceConfig), so the
+// formatting, comments and order of the rest of the config are preserved. If
+// the config is already at the latest version, the data is returned
+// unchanged.
+func MigrateConfig(data []byte) ([]byte, int, error) {
+	var doc, original yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	if err := yamlv3.Unmarshal(data, &original); err != nil {
+		return nil, 0, err
+	}
+	root := resolveNode(&doc)
+	if root == nil || root.Kind != yamlv3.MappingNode {
+		return nil, 0, ErrNoConfigVersion
+	}
+	node := mappingValue(root, "version")
+	if node == nil {
+		return nil, 0, ErrNoConfigVersion
+	}
+
+	var from int
+	if err := node.Decode(&from); err != nil {
+		return nil, 0, ErrInvalidConfigVersion
+	}
+	if _, ok := configTypes[from]; !ok {
+		return nil, 0, ErrInvalidConfigVersion
+	}
+	if from == latestConfigVersion {
+		return data, from, nil
+	}
+
+	for version := from; version < latestConfigVersion; version++ {
+		migrate, ok := configMigrations[version]
+		if !ok {
+			return nil, 0, fmt.Errorf("no migration from config version %d", version)
+		}
+		if err := migrate(root); err != nil {
+			return nil, 0, fmt.Errorf("failed to migrate config from version %d: %v", version, err)
+		}
+	}
+
+	migrated, err := spliceConfig(data, resolveNode(&original), root, latestConfigVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Make sure that the migrated config can be loaded.
+	if _, err := decodeConfig(migrated); err != nil {
+		return nil, 0, fmt.Errorf("migrated config is invalid: %v", err)
+	}
+	return migrated, from, nil
+}
+
+// configEdit replaces the lines [start, end) of a config with the given text.
+type configEdit struct {
+	start, end int
+	text       []byte
+}
+
+// spliceConfig applies the changes between the original and the migrated
+// root mappings of a config to its data. The value of the version is
+// rewritten in place, and each top-level option which was changed by a
+// migration is re-encoded in place of its original lines. Options which
+// were removed are deleted, and options which were added are appended to
+// the end of the config. All other lines are left as they are.
+func spliceConfig(data []byte, original, migrated *yamlv3.Node, version int) ([]byte, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	node := mappingValue(original, "version")
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	end := start + len(node.Value)
+	if end > len(line) || string(line[start:end]) != node.Value {
+		return nil, fmt.Errorf("unable to update config version: unexpected format")
+	}
+	updated := append([]byte{}, line[:start]...)
+	updated = append(updated, strconv.Itoa(version)...)
+	lines[node.Line-1] = append(updated, line[end:]...)
+
+	var edits []configEdit
+	var added []byte
+	for i := 0; i+1 < len(migrated.Content); i += 2 {
+		key, value := migrated.Content[i], migrated.Content[i+1]
+		if key.Value == "version" {
+			continue
+		}
+		index := optionIndex(original, key.Value)
+		if index != -1 && nodesEqual(original.Content[index+1], value) {
+			continue
+		}
+		text, err := encodeOption(key, value)
+		if err != nil {
+			return nil, err
+		}
+		if index == -1 {
+			added = append(added, text...)
+			continue
+		}
+		start, end := optionLines(lines, original, key.Value)
+		edits = append(edits, configEdit{start: start, end: end, text: text})
+	}
+	for i := 0; i+1 < len(original.Content); i += 2 {
+		if key := original.Content[i].Value; optionIndex(migrated, key) == -1 {
+			start, end := optionLines(lines, original, key)
+			edits = append(edits, configEdit{start: start, end: end})
+		}
+	}
+
+	// Apply the edits from the bottom of the config up, so that the lines of
+	// the edits which are yet to be applied do not move.
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, edit := range edits {
+		replaced := append([][]byte{}, lines[:edit.start]...)
+		replaced = append(replaced, edit.text)
+		lines = append(replaced, lines[edit.end:]...)
+	}
+
+	migratedData := bytes.Join(lines, nil)
+	if len(added) != 0 {
+		if len(migratedData) != 0 && !bytes.HasSuffix(migratedData, []byte("\n")) {
+			migratedData = append(migratedData, '\n')
+		}
+		migratedData = append(migratedData, added...)
+	}
+	return migratedData, nil
+}
+
+// optionIndex gets the index of the key node of a top-level option in the
+// root mapping of a config.
+func optionIndex(root *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// optionLines gets the range of lines [start, end) of a top-level option in
+// the original config, from the line of its key up to the next top-level
+// option. Blank and comment lines at the end of the range are left out, as
+// they separate the option from the next one, or are its head comment.
+func optionLines(lines [][]byte, root *yamlv3.Node, key string) (int, int) {
+	i := optionIndex(root, key)
+	start := root.Content[i].Line - 1
+	end := len(lines)
+	if i+2 < len(root.Content) {
+		end = root.Content[i+2].Line - 1
+	}
+	for end > start+1 {
+		trimmed := bytes.TrimSpace(lines[end-1])
+		if len(trimmed) != 0 && trimmed[0] != '#' {
+			break
+		}
+		end--
+	}
+	return start, end
+}
+
+// encodeOption encodes a top-level option of a config. The head and foot
+// comments of the option are left out, as they are kept from the original
+// config.
+func encodeOption(key, value *yamlv3.Node) ([]byte, error) {
+	k := *key
+	k.HeadComment = ""
+	k.FootComment = ""
+	v := *value
+	v.FootComment = ""
+
+	buffer := bytes.Buffer{}
+	encoder := yamlv3.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{&k, &v}}); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// nodesEqual checks whether two YAML nodes have the same content, ignoring
+// their comments and position.
+func nodesEqual(a, b *yamlv3.Node) bool {
+	if a.Kind != b.Kind || a.Tag != b.Tag || a.Value != b.Value || a.Anchor != b.Anchor || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !nodesEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffConfig gets a unified diff of the changes between the original and
+// the migrated data of the config at the given path.
+func diffConfig(path string, original, migrated []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(original)),
+		B:        splitLines(string(migrated)),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
+
+// splitLines splits text into lines, each ending with a newline. Unlike
+// difflib.SplitLines, text ending with a newline has no empty line after it.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
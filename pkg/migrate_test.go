@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	data := heredoc.Doc(`
+		# envsnap configuration
+		version: 1  # the config version
+
+		system:
+		  core:
+		  - os
+	`)
+
+	migrated, version, err := MigrateConfig([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, configV1, version)
+	assert.Equal(t, heredoc.Doc(`
+		# envsnap configuration
+		version: 2  # the config version
+
+		system:
+		  core:
+		  - os
+	`), string(migrated))
+}
+
+func TestMigrateConfig_Latest(t *testing.T) {
+	data := []byte("version: 2\nsystem:\n  core: [os]\n")
+
+	migrated, version, err := MigrateConfig(data)
+	assert.NoError(t, err)
+	assert.Equal(t, configV2, version)
+	assert.Equal(t, data, migrated)
+}
+
+func TestMigrateConfig_Err(t *testing.T) {
+	var tests = []struct {
+		data string
+		err  error
+	}{
+		{"system:\n  core: [os]\n", ErrNoConfigVersion},
+		{"- version\n", ErrNoConfigVersion},
+		{"version: one\n", ErrInvalidConfigVersion},
+		{"version: 99\n", ErrInvalidConfigVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.data, func(t *testing.T) {
+			_, _, err := MigrateConfig([]byte(test.data))
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestMigrateConfig_MigrationErr(t *testing.T) {
+	migrate := configMigrations[configV1]
+	defer func() { configMigrations[configV1] = migrate }()
+
+	configMigrations[configV1] = func(root *yamlv3.Node) error {
+		return errors.New("test error")
+	}
+	_, _, err := MigrateConfig([]byte("version: 1\n"))
+	assert.EqualError(t, err, "failed to migrate config from version 1: test error")
+}
+
+func TestMigrateConfig_Splice(t *testing.T) {
+	migrate := configMigrations[configV1]
+	defer func() { configMigrations[configV1] = migrate }()
+
+	// Only the options which the migration changes should be rewritten.
+	configMigrations[configV1] = func(root *yamlv3.Node) error {
+		run := mappingValue(mappingValue(root, "exec"), "run")
+		run.Content = append(run.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "uname -r"})
+		root.Content = append(root.Content[:6], root.Content[8:]...)
+		root.Content = append(root.Content,
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "go"},
+			&yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{
+				{Kind: yamlv3.ScalarNode, Value: "core"},
+				{Kind: yamlv3.SequenceNode, Style: yamlv3.FlowStyle, Content: []*yamlv3.Node{
+					{Kind: yamlv3.ScalarNode, Value: "version"},
+				}},
+			}},
+		)
+		return nil
+	}
+
+	data := heredoc.Doc(`
+		# envsnap configuration
+		version: 1  # the config version
+
+		# system details
+		system:
+		  core:
+		  - os
+
+		# commands
+		exec:
+		  run: [uname -a]  # the kernel
+
+		environment:
+		  variables: [PATH]
+	`)
+
+	migrated, version, err := MigrateConfig([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, configV1, version)
+	assert.Equal(t, heredoc.Doc(`
+		# envsnap configuration
+		version: 2  # the config version
+
+		# system details
+		system:
+		  core:
+		  - os
+
+		# commands
+		exec:
+		  run: [uname -a, uname -r] # the kernel
+
+		go:
+		  core: [version]
+	`), string(migrated))
+}
+
+func TestMigrateConfig_Invalid(t *testing.T) {
+	_, _, err := MigrateConfig([]byte("version: 1\nenviroment:\n  variables: [PATH]\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "migrated config is invalid")
+}
+
+func TestConfigMigrations(t *testing.T) {
+	// Every version but the latest must have a migration to the next version.
+	for version := range configTypes {
+		if version == latestConfigVersion {
+			continue
+		}
+		assert.Contains(t, configMigrations, version)
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	diff, err := diffConfig(".envsnap", []byte("# config\nversion: 1\n"), []byte("# config\nversion: 2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, heredoc.Doc(`
+		--- .envsnap
+		+++ .envsnap
+		@@ -1,2 +1,2 @@
+		 # config
+		-version: 1
+		+version: 2
+	`), diff)
+}
@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// The key in the config which sets the outputs to write snapshots to.
+const outputsKey = "outputs"
+
+// defaultOutputFormat is the format snapshots are rendered in if no output
+// format is given.
+const defaultOutputFormat = "md"
+
+// outputFormats are the formats which snapshots can be rendered in.
+var outputFormats = []string{
+	"md", "markdown",
+	"txt", "plaintext",
+	"gfm",
+	"html",
+	"yaml",
+	"json",
+	"toml",
+	"ini",
+	"env", "dotenv",
+	"cyclonedx",
+	"spdx",
+}
+
+// Output is a format to write a rendered snapshot in, along with the file to
+// write it to. If no file is given, the output is written to stdout.
+type Output struct {
+	Format string `yaml:"format"`
+	File   string `yaml:"file,omitempty"`
+}
+
+// ParseOutput parses an output given as "<format>[=<file>]".
+func ParseOutput(s string) (Output, error) {
+	parts := strings.SplitN(s, "=", 2)
+	out := Output{Format: parts[0]}
+	if len(parts) == 2 {
+		if parts[1] == "" {
+			return Output{}, fmt.Errorf("%v: no file given for output: %s", ErrInvalidOutput, s)
+		}
+		out.File = parts[1]
+	}
+	return out, out.validate()
+}
+
+// validate checks that the output has a supported format.
+func (o Output) validate() error {
+	if o.Format == "" {
+		return fmt.Errorf("%v: no format given", ErrInvalidOutput)
+	}
+	if !stringList(outputFormats).Contains(o.Format) {
+		return fmt.Errorf("%v: %s", ErrUnsupportedFormat, o.Format)
+	}
+	return nil
+}
+
+// String gets the output in the form it is parsed from.
+func (o Output) String() string {
+	if o.File == "" {
+		return o.Format
+	}
+	return o.Format + "=" + o.File
+}
+
+// resolveOutputs gets the outputs to write a snapshot to. The file, if one
+// is given, is used for every output which does not have a file of its own.
+// Outputs must not share a file, or both write to stdout.
+func resolveOutputs(outputs []Output, file string) ([]Output, error) {
+	if len(outputs) == 0 {
+		outputs = []Output{{Format: defaultOutputFormat}}
+	}
+
+	var resolved []Output
+	seen := map[string]Output{}
+	for _, out := range outputs {
+		if err := out.validate(); err != nil {
+			return nil, err
+		}
+		if out.File == "" {
+			out.File = file
+		}
+
+		dest := "stdout"
+		if out.File != "" {
+			dest = filepath.Clean(out.File)
+		}
+		if other, ok := seen[dest]; ok {
+			return nil, fmt.Errorf("%v: outputs '%s' and '%s' both write to %s", ErrInvalidOutput, other, out, dest)
+		}
+		seen[dest] = out
+		resolved = append(resolved, out)
+	}
+	return resolved, nil
+}
+
+// parseOutputs parses the outputs given with the --output flag.
+func parseOutputs(flags []string) ([]Output, error) {
+	var outputs []Output
+	for _, flag := range flags {
+		out, err := ParseOutput(flag)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
+
+// outputFiles gets the files which the outputs are written to.
+func outputFiles(outputs []Output) []string {
+	var files []string
+	for _, out := range outputs {
+		if out.File != "" {
+			files = append(files, out.File)
+		}
+	}
+	return files
+}
+
+// checkOverwrite checks that none of the files already exist, unless force
+// is set, in which case existing files are overwritten. It is checked before
+// rendering, so that an existing file is reported before any commands are
+// run.
+func checkOverwrite(force bool, files ...string) error {
+	if force {
+		return nil
+	}
+	for _, file := range files {
+		if file != "" && fileExists(file) {
+			return fmt.Errorf("%v: %s (use --force to overwrite it)", ErrOutputExists, file)
+		}
+	}
+	return nil
+}
+
+// writeOutputs writes the result in each of the outputs, either to its file
+// or to stdout. If tee is set, the outputs which are written to a file are
+// also written to stdout.
+func writeOutputs(res EnvsnapResult, outputs []Output, tee bool) error {
+	for _, out := range outputs {
+		if out.File != "" {
+			if err := res.Write(out.File, out.Format); err != nil {
+				return err
+			}
+			if !tee {
+				continue
+			}
+		}
+		if err := res.Print(out.Format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
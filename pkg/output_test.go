@@ -0,0 +1,178 @@
+package pkg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Output
+	}{
+		{"md", Output{Format: "md"}},
+		{"json=snapshot.json", Output{Format: "json", File: "snapshot.json"}},
+		{"env=out/a=b.env", Output{Format: "env", File: "out/a=b.env"}},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			out, err := ParseOutput(test.value)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+			assert.Equal(t, test.value, out.String())
+		})
+	}
+}
+
+func TestParseOutput_Err(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"", "invalid output: no format given"},
+		{"=snapshot.json", "invalid output: no format given"},
+		{"json=", "invalid output: no file given for output: json="},
+		{"jsn=snapshot.json", "unsupported format string provided: jsn"},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			_, err := ParseOutput(test.value)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestResolveOutputs(t *testing.T) {
+	outputs, err := resolveOutputs(nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "md"}}, outputs)
+
+	outputs, err = resolveOutputs(nil, "snapshot.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "md", File: "snapshot.md"}}, outputs)
+
+	outputs, err = resolveOutputs([]Output{{Format: "json", File: "snapshot.json"}, {Format: "md"}}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "json", File: "snapshot.json"}, {Format: "md"}}, outputs)
+
+	outputs, err = resolveOutputs([]Output{{Format: "json", File: "snapshot.json"}, {Format: "md"}}, "snapshot.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []Output{{Format: "json", File: "snapshot.json"}, {Format: "md", File: "snapshot.md"}}, outputs)
+}
+
+func TestResolveOutputs_Err(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []Output
+		file    string
+		err     string
+	}{
+		{
+			name:    "stdout",
+			outputs: []Output{{Format: "json"}, {Format: "md"}},
+			err:     "invalid output: outputs 'json' and 'md' both write to stdout",
+		},
+		{
+			name:    "file",
+			outputs: []Output{{Format: "json", File: "./out.txt"}, {Format: "md"}},
+			file:    "out.txt",
+			err:     "invalid output: outputs 'json=./out.txt' and 'md=out.txt' both write to out.txt",
+		},
+		{
+			name:    "format",
+			outputs: []Output{{Format: "xml"}},
+			err:     "unsupported format string provided: xml",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := resolveOutputs(test.outputs, test.file)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	buffer := bytes.Buffer{}
+	res := NewV1EnvsnapResult()
+	res.System = sys
+	res.out = &buffer
+
+	err = writeOutputs(&res, []Output{
+		{Format: "json", File: filepath.Join(dir, "snapshot.json")},
+		{Format: "txt"},
+		{Format: "yaml", File: filepath.Join(dir, "snapshot.yaml")},
+	}, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "System\n------\nos:             testOS\n\n", buffer.String())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "snapshot.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"system":{"os":"testOS"}}`, string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "snapshot.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 1\nsystem:\n  os: testOS\n", string(data))
+}
+
+func TestWriteOutputs_Err(t *testing.T) {
+	res := NewV1EnvsnapResult()
+	err := writeOutputs(&res, []Output{{Format: "xml"}}, false)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestWriteOutputs_Tee(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	buffer := bytes.Buffer{}
+	res := NewV1EnvsnapResult()
+	res.System = sys
+	res.out = &buffer
+
+	file := filepath.Join(dir, "out", "snapshot.json")
+	err = writeOutputs(&res, []Output{{Format: "json", File: file}}, true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"version":1,"system":{"os":"testOS"}}`+"\n", buffer.String())
+
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"system":{"os":"testOS"}}`, string(data))
+}
+
+func TestOutputFiles(t *testing.T) {
+	assert.Nil(t, outputFiles([]Output{{Format: "md"}}))
+	assert.Equal(t, []string{"a.json", "b.md"}, outputFiles([]Output{{Format: "json", File: "a.json"}, {Format: "txt"}, {Format: "md", File: "b.md"}}))
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	missing := file.Name() + ".missing"
+
+	assert.NoError(t, checkOverwrite(false))
+	assert.NoError(t, checkOverwrite(false, "", missing))
+	assert.NoError(t, checkOverwrite(true, missing, file.Name()))
+
+	err = checkOverwrite(false, missing, file.Name())
+	assert.EqualError(t, err, "output file already exists: "+file.Name()+" (use --force to overwrite it)")
+}
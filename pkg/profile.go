@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// configSections are the names of the config sections which can be rendered,
+// as they are keyed in the config.
+var configSections = []string{
+	"system", "environment", "exec", "python", "go",
+}
+
+// Profile defines a named subset of the configured sections to render, which
+// can be selected with `envsnap render --profile <name>`.
+type Profile struct {
+	Description string   `yaml:"description,omitempty"`
+	Extends     string   `yaml:"extends,omitempty"`
+	Sections    []string `yaml:"sections,omitempty"`
+}
+
+// resolveProfile gets the sections selected by the named profile, including
+// the sections of any profile it extends. Sections are returned in the order
+// in which they are rendered.
+func (c V1EnvsnapConfig) resolveProfile(name string) ([]string, error) {
+	var (
+		selected stringList
+		chain    []string
+	)
+
+	for name != "" {
+		for _, visited := range chain {
+			if visited == name {
+				return nil, fmt.Errorf("profile extends itself: %s", strings.Join(append(chain, name), " -> "))
+			}
+		}
+		chain = append(chain, name)
+
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("%v: %s", ErrUnknownProfile, name)
+		}
+		for _, section := range profile.Sections {
+			if !stringList(configSections).Contains(section) {
+				return nil, fmt.Errorf("profile %s: unsupported section: %s", name, section)
+			}
+		}
+		selected = append(selected, profile.Sections...)
+		name = profile.Extends
+	}
+
+	// Return the selected sections in the order they are rendered.
+	var sections []string
+	for _, section := range configSections {
+		if selected.Contains(section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// WithProfile gets a copy of the config which only renders the sections
+// selected by the named profile.
+//
+// If no profile name is given, the default profile is used. If there is no
+// default profile, the config is returned unchanged.
+func (c V1EnvsnapConfig) WithProfile(name string) (EnvsnapConfig, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return &c, nil
+	}
+
+	sections, err := c.resolveProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	selected := stringList(sections)
+
+	cfg := c
+	if !selected.Contains("system") {
+		cfg.System = SystemConfig{}
+	}
+	if !selected.Contains("environment") {
+		cfg.Environment = EnvConfig{}
+	}
+	if !selected.Contains("exec") {
+		cfg.Exec = ExecConfig{}
+	}
+	if !selected.Contains("python") {
+		cfg.Python = PythonConfig{}
+	}
+	if !selected.Contains("go") {
+		cfg.Golang = GolangConfig{}
+	}
+	return &cfg, nil
+}
+
+// PrintProfiles prints out the profiles defined in the config.
+//
+// Profiles are listed alphabetically, along with the sections they select
+// and their description. The default profile is marked with an asterisk.
+func (c V1EnvsnapConfig) PrintProfiles(writer io.Writer) error {
+	if len(c.Profiles) == 0 {
+		fmt.Fprintln(writer, "no profiles defined in config")
+		return nil
+	}
+
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := NewTabWriter(writer)
+	defer tw.Flush()
+
+	for _, name := range names {
+		sections, err := c.resolveProfile(name)
+		if err != nil {
+			return err
+		}
+		marker := " "
+		if name == c.DefaultProfile {
+			marker = "*"
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", marker, name, strings.Join(sections, ", "), c.Profiles[name].Description)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newProfileConfig() V1EnvsnapConfig {
+	return V1EnvsnapConfig{
+		System:      SystemConfig{Core: []Item{{Value: "os"}}},
+		Environment: EnvConfig{Variables: []Item{{Value: "PATH"}}},
+		Exec:        ExecConfig{Run: []Item{{Value: "ls"}}},
+		Python:      PythonConfig{Core: []Item{{Value: "version"}}},
+		Golang:      GolangConfig{Core: []Item{{Value: "version"}}},
+		Profiles: map[string]Profile{
+			"minimal": {
+				Description: "Minimal snapshot for issues",
+				Sections:    []string{"system"},
+			},
+			"full": {
+				Extends:  "minimal",
+				Sections: []string{"environment", "exec", "python", "go"},
+			},
+			"cycle-a": {Extends: "cycle-b"},
+			"cycle-b": {Extends: "cycle-a"},
+			"unknown": {Extends: "not-a-profile"},
+			"bad":     {Sections: []string{"foo"}},
+		},
+	}
+}
+
+func TestV1EnvsnapConfig_WithProfile(t *testing.T) {
+	cfg := newProfileConfig()
+
+	p, err := cfg.WithProfile("minimal")
+	assert.NoError(t, err)
+
+	actual := p.(*V1EnvsnapConfig)
+	assert.Equal(t, cfg.System, actual.System)
+	assert.Empty(t, actual.Environment.Variables)
+	assert.Empty(t, actual.Exec.Run)
+	assert.Empty(t, actual.Python.Core)
+	assert.Empty(t, actual.Golang.Core)
+
+	// The original config should not be modified.
+	assert.NotEmpty(t, cfg.Exec.Run)
+}
+
+func TestV1EnvsnapConfig_WithProfile_Extends(t *testing.T) {
+	cfg := newProfileConfig()
+
+	p, err := cfg.WithProfile("full")
+	assert.NoError(t, err)
+	assert.Equal(t, &cfg, p)
+}
+
+func TestV1EnvsnapConfig_WithProfile_Default(t *testing.T) {
+	cfg := newProfileConfig()
+
+	// No default profile, so the config is unchanged.
+	p, err := cfg.WithProfile("")
+	assert.NoError(t, err)
+	assert.Equal(t, &cfg, p)
+
+	cfg.DefaultProfile = "minimal"
+	p, err = cfg.WithProfile("")
+	assert.NoError(t, err)
+	assert.Empty(t, p.(*V1EnvsnapConfig).Exec.Run)
+}
+
+func TestV1EnvsnapConfig_WithProfile_Err(t *testing.T) {
+	var tests = []struct {
+		profile string
+		err     string
+	}{
+		{"not-a-profile", "profile not found in config: not-a-profile"},
+		{"unknown", "profile not found in config: not-a-profile"},
+		{"cycle-a", "profile extends itself: cycle-a -> cycle-b -> cycle-a"},
+		{"bad", "profile bad: unsupported section: foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			cfg := newProfileConfig()
+			p, err := cfg.WithProfile(tt.profile)
+			assert.Nil(t, p)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestV1EnvsnapConfig_PrintProfiles(t *testing.T) {
+	cfg := newProfileConfig()
+	cfg.DefaultProfile = "minimal"
+	cfg.Profiles = map[string]Profile{
+		"minimal": cfg.Profiles["minimal"],
+		"full":    cfg.Profiles["full"],
+	}
+
+	out := bytes.Buffer{}
+	err := cfg.PrintProfiles(&out)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"  full      system, environment, exec, python, go   \n"+
+			"* minimal   system                                  Minimal snapshot for issues\n",
+		out.String(),
+	)
+}
+
+func TestV1EnvsnapConfig_PrintProfiles_None(t *testing.T) {
+	cfg := V1EnvsnapConfig{}
+
+	out := bytes.Buffer{}
+	err := cfg.PrintProfiles(&out)
+	assert.NoError(t, err)
+	assert.Equal(t, "no profiles defined in config\n", out.String())
+}
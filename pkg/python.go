@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"strings"
 	"text/template"
 
@@ -12,16 +13,22 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// pythonCoreOptions are the supported values for the "python.core" option.
+var pythonCoreOptions = []string{
+	"version", "py2", "py3",
+}
+
 // PythonConfig defines the configuration for the "python" source.
 type PythonConfig struct {
-	Core []string           `yaml:"core,omitempty"`
+	When *Condition         `yaml:"when,omitempty"`
+	Core Items              `yaml:"core,omitempty"`
 	Deps DependenciesConfig `yaml:"dependencies,omitempty"`
 }
 
 // DependenciesConfig defines the Python configuration for specifying
 // package dependencies.
 type DependenciesConfig struct {
-	Packages []string `yaml:"packages,omitempty"`
+	Packages Items `yaml:"packages,omitempty"`
 
 	// todo: not yet implemented
 	//From []string `yaml:"from"`
@@ -33,9 +40,18 @@ func (c PythonConfig) Render() (Result, error) {
 	l.Debug("starting render")
 
 	result := NewPythonResult()
+	if !c.When.Applies() {
+		l.Debug("skipping render: condition does not apply")
+		return result, nil
+	}
 
 	// Core Options
-	for _, opt := range c.Core {
+	for _, item := range c.Core {
+		if !item.applies(l) {
+			continue
+		}
+		opt := item.Value
+		result.addMeta(opt, item)
 		switch opt {
 		case "version":
 			if !binExists("python") {
@@ -65,7 +81,7 @@ func (c PythonConfig) Render() (Result, error) {
 				l.Debug("command error: failed to get python version")
 				cliWarnings.Add("python.core.version", "failed to get version from stdout or stderr")
 			}
-			result.Version = toSlice(ver)[1]
+			result.Version = item.redact(toSlice(ver)[1])
 
 		case "py2":
 			if !binExists("python2") {
@@ -94,7 +110,7 @@ func (c PythonConfig) Render() (Result, error) {
 				l.Debug("command error: failed to get python2 version")
 				cliWarnings.Add("python.core.py2", "failed to get version from stdout or stderr")
 			}
-			result.VersionPy2 = toSlice(ver)[1]
+			result.VersionPy2 = item.redact(toSlice(ver)[1])
 
 		case "py3":
 			if !binExists("python3") {
@@ -123,7 +139,7 @@ func (c PythonConfig) Render() (Result, error) {
 				l.Debug("command error: failed to get python3 version")
 				cliWarnings.Add("python.core.py3", "failed to get version from stdout or stderr")
 			}
-			result.VersionPy3 = toSlice(ver)[1]
+			result.VersionPy3 = item.redact(toSlice(ver)[1])
 
 		default:
 			return result, fmt.Errorf("unsupported option for python.core: %s", opt)
@@ -135,7 +151,11 @@ func (c PythonConfig) Render() (Result, error) {
 		if !binExists("pip") {
 			cliWarnings.Add("python.deps.packages", "pip executable not found")
 		} else {
-			for _, dep := range c.Deps.Packages {
+			for _, item := range c.Deps.Packages {
+				if !item.applies(l) {
+					continue
+				}
+				dep := item.Value
 
 				stdout, stderr, err := runCommand("pip", "show", dep)
 				if err != nil {
@@ -149,13 +169,15 @@ func (c PythonConfig) Render() (Result, error) {
 						"python dependency not found: '%s'", dep,
 					)
 					result.Deps[dep] = ""
+					result.addMeta(dep, item)
 					continue
 				}
 
 				fields := strings.Split(string(stdout.String()), "\n")
 				name := strings.Split(fields[0], " ")[1]
 				version := strings.Split(fields[1], " ")[1]
-				result.Deps[name] = version
+				result.Deps[name] = item.redact(version)
+				result.addMeta(name, item)
 			}
 		}
 	}
@@ -203,6 +225,30 @@ func (r PythonResult) IsEmpty() bool {
 	return r.Version == "" && r.VersionPy2 == "" && r.VersionPy3 == "" && len(r.Deps) == 0
 }
 
+// withoutHidden gets a copy of the result without the hidden items. The
+// metadata of dependencies is keyed by the name of the dependency.
+func (r PythonResult) withoutHidden() PythonResult {
+	deps := make(map[string]string, len(r.Deps))
+	for k, v := range r.Deps {
+		deps[k] = v
+	}
+	for _, key := range r.hidden() {
+		switch key {
+		case "version":
+			r.Version = ""
+		case "py2":
+			r.VersionPy2 = ""
+		case "py3":
+			r.VersionPy3 = ""
+		default:
+			delete(deps, key)
+		}
+	}
+	r.Deps = deps
+	r.Meta = r.visibleMeta()
+	return r
+}
+
 // Markdown renders the PythonResult to markdown.
 func (r PythonResult) Markdown() ([]byte, error) {
 	log.WithField("src", "python").Debug("rendering to markdown")
@@ -212,14 +258,15 @@ func (r PythonResult) Markdown() ([]byte, error) {
 	}
 
 	md := heredoc.Doc(`
-		**Python**{{ if .Version }}
-		- _version_: {{ .Version }}{{ end }}{{ if .VersionPy2 }}
-		- _py2_: {{ .VersionPy2 }}{{ end }}{{ if .VersionPy3 }}
-		- _py3_: {{ .VersionPy3 }}{{ end }}{{ if .Deps }}
+		**Python**{{ if and .Version ($.Shown "version") }}
+		- _{{ $.Label "version" "version" }}_: {{ .Version }}{{ $.Describe "version" }}{{ end }}{{ if and .VersionPy2 ($.Shown "py2") }}
+		- _{{ $.Label "py2" "py2" }}_: {{ .VersionPy2 }}{{ $.Describe "py2" }}{{ end }}{{ if and .VersionPy3 ($.Shown "py3") }}
+		- _{{ $.Label "py3" "py3" }}_: {{ .VersionPy3 }}{{ $.Describe "py3" }}{{ end }}{{ if .Deps }}
 		- _dependencies_:
 		  {{ .CodeFence }}
-		  {{ range $key, $val := .Deps }}{{ $key }}=={{ $val }}
-		  {{ end }}{{ .CodeFence }}{{ end }}
+		  {{ range $key, $val := .Deps }}{{ if $.Shown $key }}{{ with $.Comment $key }}# {{ . }}
+		  {{ end }}{{ $key }}=={{ $val }}
+		  {{ end }}{{ end }}{{ .CodeFence }}{{ end }}
 	`)
 	t := template.Must(template.New("python-md").Parse(md))
 
@@ -240,13 +287,13 @@ func (r PythonResult) Plaintext() ([]byte, error) {
 
 	plaintext := heredoc.Doc(`
 		Python
-		------{{ if .Version }}
-		version:  {{ .Version }}{{ end }}{{ if .VersionPy2 }}
-		py2:      {{ .VersionPy2 }}{{ end }}{{ if .VersionPy3 }}
-		py3:      {{ .VersionPy3 }}{{ end }}{{ if .Deps }}
+		------{{ if and .Version ($.Shown "version") }}
+		{{ printf "%-9s" (print ($.Label "version" "version") ":") }} {{ .Version }}{{ $.Describe "version" }}{{ end }}{{ if and .VersionPy2 ($.Shown "py2") }}
+		{{ printf "%-9s" (print ($.Label "py2" "py2") ":") }} {{ .VersionPy2 }}{{ $.Describe "py2" }}{{ end }}{{ if and .VersionPy3 ($.Shown "py3") }}
+		{{ printf "%-9s" (print ($.Label "py3" "py3") ":") }} {{ .VersionPy3 }}{{ $.Describe "py3" }}{{ end }}{{ if .Deps }}
 		dependencies:
-		{{ range $key, $val := .Deps }}- {{ $key }}=={{ $val }}
-		{{ end }}{{ end -}}
+		{{ range $key, $val := .Deps }}{{ if $.Shown $key }}- {{ $key }}=={{ $val }}{{ $.Describe $key }}
+		{{ end }}{{ end }}{{ end -}}
 	`)
 
 	t := template.Must(template.New("python-txt").Parse(plaintext))
@@ -258,6 +305,73 @@ func (r PythonResult) Plaintext() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// GFM renders the PythonResult to GitHub-flavored markdown.
+func (r PythonResult) GFM() ([]byte, error) {
+	log.WithField("src", "python").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>Python</summary>
+		{{ if or .Version .VersionPy2 .VersionPy3 }}
+		| Key | Value |
+		| :--- | :--- |{{ if and .Version ($.Shown "version") }}
+		| {{ $.Cell ($.Label "version" "version") }} | {{ $.Cell (print .Version ($.Describe "version")) }} |{{ end }}{{ if and .VersionPy2 ($.Shown "py2") }}
+		| {{ $.Cell ($.Label "py2" "py2") }} | {{ $.Cell (print .VersionPy2 ($.Describe "py2")) }} |{{ end }}{{ if and .VersionPy3 ($.Shown "py3") }}
+		| {{ $.Cell ($.Label "py3" "py3") }} | {{ $.Cell (print .VersionPy3 ($.Describe "py3")) }} |{{ end }}
+		{{ end }}{{ if .Deps }}
+		**dependencies**
+
+		{{ .CodeFence }}
+		{{ range $key, $val := .Deps }}{{ if $.Shown $key }}{{ with $.Comment $key }}# {{ . }}
+		{{ end }}{{ $key }}=={{ $val }}
+		{{ end }}{{ end }}{{ .CodeFence }}
+		{{ end }}
+		</details>
+	`)
+	t := template.Must(template.New("python-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the PythonResult to an HTML section.
+func (r PythonResult) HTML() ([]byte, error) {
+	log.WithField("src", "python").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>Python</summary>
+		<table>{{ if and .Version ($.Shown "version") }}
+		<tr><th>{{ $.Label "version" "version" }}</th><td>{{ .Version }}{{ with $.Description "version" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .VersionPy2 ($.Shown "py2") }}
+		<tr><th>{{ $.Label "py2" "py2" }}</th><td>{{ .VersionPy2 }}{{ with $.Description "py2" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .VersionPy3 ($.Shown "py3") }}
+		<tr><th>{{ $.Label "py3" "py3" }}</th><td>{{ .VersionPy3 }}{{ with $.Description "py3" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if .Deps }}
+		<tr><th>dependencies</th><td><div class="code"><button class="copy" type="button">Copy</button><pre>
+		{{- range $key, $val := .Deps }}{{ if $.Shown $key }}{{ with $.Comment $key }}# {{ . }}
+		{{ end }}{{ $key }}=={{ $val }}
+		{{ end }}{{ end -}}
+		</pre></div></td></tr>{{ end }}
+		</table>
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("python-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // YAML renders the PythonResult to YAML.
 func (r PythonResult) YAML() ([]byte, error) {
 	log.WithField("src", "python").Debug("rendering to YAML")
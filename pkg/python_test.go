@@ -16,7 +16,7 @@ func TestPythonConfig_Render(t *testing.T) {
 	hasBin := binExists("python")
 
 	cfg := PythonConfig{
-		Core: []string{"version"},
+		Core: []Item{{Value: "version"}},
 	}
 
 	out, err := cfg.Render()
@@ -49,7 +49,7 @@ func TestPythonConfig_Render2(t *testing.T) {
 	hasBin := binExists("python2")
 
 	cfg := PythonConfig{
-		Core: []string{"py2"},
+		Core: []Item{{Value: "py2"}},
 	}
 
 	out, err := cfg.Render()
@@ -82,7 +82,7 @@ func TestPythonConfig_Render3(t *testing.T) {
 	hasBin := binExists("python3")
 
 	cfg := PythonConfig{
-		Core: []string{"py3"},
+		Core: []Item{{Value: "py3"}},
 	}
 
 	out, err := cfg.Render()
@@ -116,7 +116,7 @@ func TestPythonConfig_Render4(t *testing.T) {
 
 	cfg := PythonConfig{
 		Deps: DependenciesConfig{
-			Packages: []string{"setuptools"},
+			Packages: []Item{{Value: "setuptools"}},
 		},
 	}
 
@@ -139,7 +139,7 @@ func TestPythonConfig_Render4(t *testing.T) {
 
 func TestPythonConfig_Render_Err(t *testing.T) {
 	cfg := PythonConfig{
-		Core: []string{"not-an-option"},
+		Core: []Item{{Value: "not-an-option"}},
 	}
 
 	_, err := cfg.Render()
@@ -210,6 +210,78 @@ func TestPythonResult_Markdown(t *testing.T) {
 	assert.Equal(t, expected, string(data))
 }
 
+func TestPythonResult_Meta(t *testing.T) {
+	r := NewPythonResult()
+	r.Version = "3.6.9"
+	r.VersionPy2 = "2.7.11"
+	r.Deps = map[string]string{
+		"foo": "1.2.3",
+		"bar": "0.1.0",
+	}
+	r.addMeta("version", Item{Value: "version", Label: "interpreter"})
+	r.addMeta("py2", Item{Value: "py2", Hidden: true})
+	r.addMeta("foo", Item{Value: "foo", Description: "web framework"})
+	r.addMeta("bar", Item{Value: "bar", Hidden: true})
+
+	data, err := r.Markdown()
+	assert.NoError(t, err)
+	assert.Equal(t, "**Python**\n- _interpreter_: 3.6.9\n- _dependencies_:\n  ```\n  # web framework\n  foo==1.2.3\n  ```\n", string(data))
+
+	data, err = r.Plaintext()
+	assert.NoError(t, err)
+	assert.Equal(t, "Python\n------\ninterpreter: 3.6.9\ndependencies:\n- foo==1.2.3 (web framework)\n", string(data))
+}
+
+func TestPythonResult_GFM(t *testing.T) {
+	r := NewPythonResult()
+	r.Version = "3.6.9"
+	r.Deps = map[string]string{
+		"foo": "1.2.3",
+	}
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Python</summary>\n\n| Key | Value |\n| :--- | :--- |\n| version | 3.6.9 |\n\n**dependencies**\n\n```\nfoo==1.2.3\n```\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestPythonResult_GFM_DepsOnly(t *testing.T) {
+	r := NewPythonResult()
+	r.Deps = map[string]string{
+		"foo": "1.2.3",
+	}
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>Python</summary>\n\n**dependencies**\n\n```\nfoo==1.2.3\n```\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestPythonResult_HTML(t *testing.T) {
+	r := NewPythonResult()
+	r.Version = "3.6.9"
+	r.Deps = map[string]string{
+		"foo": "1.2.3",
+		"bar": "0.1.0",
+	}
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+
+	expected := "<details class=\"section\" open>\n<summary>Python</summary>\n<table>\n<tr><th>version</th><td>3.6.9</td></tr>\n<tr><th>dependencies</th><td><div class=\"code\"><button class=\"copy\" type=\"button\">Copy</button><pre>bar==0.1.0\nfoo==1.2.3\n</pre></div></td></tr>\n</table>\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestPythonResult_HTML_Empty(t *testing.T) {
+	r := NewPythonResult()
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
 func TestPythonResult_Markdown_Empty(t *testing.T) {
 	r := NewPythonResult()
 
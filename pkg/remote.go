@@ -0,0 +1,239 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of remote config sources.
+const (
+	remoteGithub    = "github"
+	remoteGitlab    = "gitlab"
+	remoteBitbucket = "bitbucket"
+	remoteGit       = "git"
+	remoteHTTP      = "http"
+	remoteFile      = "file"
+)
+
+// hostedRemotes maps the host prefix of a hosted repository reference to the
+// kind of remote it references.
+var hostedRemotes = map[string]string{
+	"github.com/":    remoteGithub,
+	"gitlab.com/":    remoteGitlab,
+	"bitbucket.org/": remoteBitbucket,
+}
+
+// RemoteRef is a parsed reference to a config file in a remote source.
+//
+// The supported reference formats are:
+//
+//	github.com/<owner>/<repo>[//<path>][@<ref>]
+//	gitlab.com/<group>/<project>[//<path>][@<ref>]
+//	bitbucket.org/<owner>/<repo>[//<path>][@<ref>]
+//	git+<url>[//<path>][@<ref>], or any <url> ending in .git
+//	https://<host>/<path>
+//	file://<path>
+//
+// For repository references, the path is the path to the config file within
+// the repository (".envsnap" by default), and the ref may be a branch name,
+// tag, or commit. If no ref is given, the default branch is used.
+type RemoteRef struct {
+	Kind string
+
+	// Repo identifies the repository: "<owner>/<repo>" for hosted repos, or
+	// the clone URL for git repos. For http and file references, it is the
+	// URL or path of the config file itself.
+	Repo string
+	Path string
+	Ref  string
+}
+
+// isRemoteConfig checks whether the config path references a remote config.
+func isRemoteConfig(path string) bool {
+	_, ok := remoteKind(path)
+	return ok
+}
+
+// remoteKind gets the kind of remote referenced by the config path.
+func remoteKind(s string) (string, bool) {
+	for prefix, kind := range hostedRemotes {
+		if strings.HasPrefix(s, prefix) {
+			return kind, true
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "git+"), strings.HasPrefix(s, "git@"):
+		return remoteGit, true
+	case strings.HasPrefix(s, "https://"), strings.HasPrefix(s, "http://"), strings.HasPrefix(s, "ssh://"):
+		repo, _ := splitGitRef(s)
+		if strings.HasSuffix(repo, ".git") || strings.HasPrefix(s, "ssh://") {
+			return remoteGit, true
+		}
+		return remoteHTTP, true
+	case strings.HasPrefix(s, "file://"):
+		return remoteFile, true
+	}
+	return "", false
+}
+
+// ParseRemoteRef parses a reference to a remote config.
+func ParseRemoteRef(s string) (RemoteRef, error) {
+	kind, ok := remoteKind(s)
+	if !ok {
+		return RemoteRef{}, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, s)
+	}
+
+	switch kind {
+	case remoteGithub, remoteGitlab, remoteBitbucket:
+		r := RemoteRef{Kind: kind, Path: configFile}
+		rest := s[strings.Index(s, "/")+1:]
+		if i := strings.Index(rest, "@"); i != -1 {
+			rest, r.Ref = rest[:i], rest[i+1:]
+		}
+		if i := strings.Index(rest, "//"); i != -1 {
+			rest, r.Path = rest[:i], rest[i+2:]
+		}
+		r.Repo = strings.Trim(rest, "/")
+
+		parts := strings.Split(r.Repo, "/")
+		if kind == remoteGithub && len(parts) != 2 {
+			return r, ErrInvalidGithubURL
+		}
+		if len(parts) < 2 || r.Path == "" || isOptionLike(r.Ref) {
+			return r, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, s)
+		}
+		return r, nil
+
+	case remoteGit:
+		r := RemoteRef{Kind: kind, Path: configFile}
+		rest := strings.TrimPrefix(s, "git+")
+		rest, r.Ref = splitGitRef(rest)
+
+		// Skip over the "//" following the URL scheme, if any, when looking
+		// for the path separator.
+		start := 0
+		if i := strings.Index(rest, "://"); i != -1 {
+			start = i + 3
+		}
+		if i := strings.Index(rest[start:], "//"); i != -1 {
+			rest, r.Path = rest[:start+i], rest[start+i+2:]
+		}
+		r.Repo = rest
+		if r.Repo == "" || r.Path == "" || isOptionLike(r.Repo) || isOptionLike(r.Ref) {
+			return r, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, s)
+		}
+		return r, nil
+
+	case remoteHTTP:
+		if _, err := url.Parse(s); err != nil {
+			return RemoteRef{}, fmt.Errorf("%v: %s: %v", ErrInvalidRemoteRef, s, err)
+		}
+		return RemoteRef{Kind: kind, Repo: s}, nil
+
+	default:
+		p := strings.TrimPrefix(s, "file://")
+		if p == "" {
+			return RemoteRef{}, fmt.Errorf("%v: %s", ErrInvalidRemoteRef, s)
+		}
+		return RemoteRef{Kind: kind, Repo: p}, nil
+	}
+}
+
+// splitGitRef splits the ref from a git URL. Since git URLs may contain an
+// "@" for the user (e.g. "git@github.com:owner/repo.git"), the ref is only
+// split off if the "@" is in the last segment of the URL.
+func splitGitRef(s string) (string, string) {
+	at := strings.LastIndex(s, "@")
+	if at == -1 || at < strings.LastIndexAny(s, "/:") {
+		return s, ""
+	}
+	return s[:at], s[at+1:]
+}
+
+// String gets the reference in its string form.
+func (r RemoteRef) String() string {
+	switch r.Kind {
+	case remoteHTTP:
+		return r.Repo
+	case remoteFile:
+		return "file://" + r.Repo
+	}
+
+	var s string
+	switch r.Kind {
+	case remoteGit:
+		s = "git+" + r.Repo
+	default:
+		for prefix, kind := range hostedRemotes {
+			if kind == r.Kind {
+				s = prefix + r.Repo
+			}
+		}
+	}
+	if r.Path != configFile {
+		s += "//" + r.Path
+	}
+	if r.Ref != "" {
+		s += "@" + r.Ref
+	}
+	return s
+}
+
+// Join resolves a path relative to the config file, returning a reference
+// to that path in the same remote source (and at the same ref).
+func (r RemoteRef) Join(rel string) RemoteRef {
+	joined := r
+	switch r.Kind {
+	case remoteHTTP:
+		base, err := url.Parse(r.Repo)
+		if err != nil {
+			return joined
+		}
+		ref, err := url.Parse(rel)
+		if err != nil {
+			return joined
+		}
+		joined.Repo = base.ResolveReference(ref).String()
+	case remoteFile:
+		if !filepath.IsAbs(rel) {
+			rel = filepath.Join(filepath.Dir(r.Repo), rel)
+		}
+		joined.Repo = rel
+	default:
+		if strings.HasPrefix(rel, "/") {
+			joined.Path = strings.TrimPrefix(path.Clean(rel), "/")
+		} else {
+			joined.Path = path.Join(path.Dir(r.Path), rel)
+		}
+	}
+	return joined
+}
+
+// sameSource checks whether the other reference is to a file in the same
+// remote source as this one: the same repository (at any ref) for repository
+// references, or the same host for HTTP(S) references. Paths which resolve to
+// outside of the repository are not in it.
+func (r RemoteRef) sameSource(other RemoteRef) bool {
+	if r.Kind != other.Kind {
+		return false
+	}
+	switch r.Kind {
+	case remoteHTTP:
+		base, err := url.Parse(r.Repo)
+		if err != nil {
+			return false
+		}
+		u, err := url.Parse(other.Repo)
+		if err != nil {
+			return false
+		}
+		return base.Scheme == u.Scheme && strings.EqualFold(base.Host, u.Host)
+	case remoteFile:
+		return true
+	default:
+		return r.Repo == other.Repo && other.Path != ".." && !strings.HasPrefix(other.Path, "../")
+	}
+}
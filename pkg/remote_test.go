@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRemoteConfig(t *testing.T) {
+	var tests = []struct {
+		path   string
+		remote bool
+	}{
+		{"github.com/foo/bar", true},
+		{"gitlab.com/foo/bar", true},
+		{"bitbucket.org/foo/bar", true},
+		{"git+https://example.com/foo/bar", true},
+		{"git@example.com:foo/bar.git", true},
+		{"https://example.com/.envsnap", true},
+		{"file:///tmp/.envsnap", true},
+		{".envsnap", false},
+		{"/tmp/.envsnap", false},
+		{"github.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.remote, isRemoteConfig(tt.path))
+		})
+	}
+}
+
+func TestParseRemoteRef(t *testing.T) {
+	var tests = []struct {
+		in  string
+		ref RemoteRef
+	}{
+		{"github.com/foo/bar", RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: ".envsnap"}},
+		{"github.com/foo/bar@v1", RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: ".envsnap", Ref: "v1"}},
+		{"github.com/foo/bar@feature/x", RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: ".envsnap", Ref: "feature/x"}},
+		{"github.com/foo/bar//cfg/base.yml@v1", RemoteRef{Kind: remoteGithub, Repo: "foo/bar", Path: "cfg/base.yml", Ref: "v1"}},
+		{"gitlab.com/group/sub/project//base.yml", RemoteRef{Kind: remoteGitlab, Repo: "group/sub/project", Path: "base.yml"}},
+		{"bitbucket.org/foo/bar@main", RemoteRef{Kind: remoteBitbucket, Repo: "foo/bar", Path: ".envsnap", Ref: "main"}},
+		{"git+https://example.com/foo/bar//base.yml@v1", RemoteRef{Kind: remoteGit, Repo: "https://example.com/foo/bar", Path: "base.yml", Ref: "v1"}},
+		{"https://example.com/foo/bar.git@abc123", RemoteRef{Kind: remoteGit, Repo: "https://example.com/foo/bar.git", Path: ".envsnap", Ref: "abc123"}},
+		{"git@example.com:foo/bar.git", RemoteRef{Kind: remoteGit, Repo: "git@example.com:foo/bar.git", Path: ".envsnap"}},
+		{"ssh://git@example.com/foo/bar.git//base.yml", RemoteRef{Kind: remoteGit, Repo: "ssh://git@example.com/foo/bar.git", Path: "base.yml"}},
+		{"https://example.com/cfg/.envsnap", RemoteRef{Kind: remoteHTTP, Repo: "https://example.com/cfg/.envsnap"}},
+		{"file:///tmp/.envsnap", RemoteRef{Kind: remoteFile, Repo: "/tmp/.envsnap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ref, err := ParseRemoteRef(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+		})
+	}
+}
+
+func TestParseRemoteRef_Error(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{"github.com/foo", ErrInvalidGithubURL.Error()},
+		{"github.com/foo/bar/baz", ErrInvalidGithubURL.Error()},
+		{"gitlab.com/foo", "invalid remote config reference: gitlab.com/foo"},
+		{"bitbucket.org/foo/bar//", "invalid remote config reference: bitbucket.org/foo/bar//"},
+		{"file://", "invalid remote config reference: file://"},
+		{"git+--upload-pack=touch /tmp/x@.", "invalid remote config reference: git+--upload-pack=touch /tmp/x@."},
+		{"git+https://example.com/repo.git@--output=x", "invalid remote config reference: git+https://example.com/repo.git@--output=x"},
+		{"github.com/foo/bar@--output=x", "invalid remote config reference: github.com/foo/bar@--output=x"},
+		{".envsnap", "invalid remote config reference: .envsnap"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := ParseRemoteRef(tt.in)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestRemoteRef_String(t *testing.T) {
+	var tests = []string{
+		"github.com/foo/bar",
+		"github.com/foo/bar@v1",
+		"gitlab.com/group/sub/project//base.yml",
+		"bitbucket.org/foo/bar//cfg/base.yml@main",
+		"git+https://example.com/foo/bar.git//base.yml@v1",
+		"git+git@example.com:foo/bar.git",
+		"https://example.com/cfg/.envsnap",
+		"file:///tmp/.envsnap",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			ref, err := ParseRemoteRef(tt)
+			assert.NoError(t, err)
+			assert.Equal(t, tt, ref.String())
+		})
+	}
+}
+
+func TestRemoteRef_Join(t *testing.T) {
+	var tests = []struct {
+		from     string
+		rel      string
+		expected string
+	}{
+		{"github.com/foo/bar", "base.yml", "github.com/foo/bar//base.yml"},
+		{"github.com/foo/bar//cfg/.envsnap@v1", "base.yml", "github.com/foo/bar//cfg/base.yml@v1"},
+		{"github.com/foo/bar//cfg/.envsnap", "../base.yml", "github.com/foo/bar//base.yml"},
+		{"github.com/foo/bar//cfg/.envsnap", "/base.yml", "github.com/foo/bar//base.yml"},
+		{"git+https://example.com/foo/bar.git@v1", "base.yml", "git+https://example.com/foo/bar.git//base.yml@v1"},
+		{"https://example.com/cfg/.envsnap", "base.yml", "https://example.com/cfg/base.yml"},
+		{"https://example.com/cfg/.envsnap", "/base.yml", "https://example.com/base.yml"},
+		{"file:///tmp/cfg/.envsnap", "../base.yml", "file:///tmp/base.yml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.rel, func(t *testing.T) {
+			ref, err := ParseRemoteRef(tt.from)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ref.Join(tt.rel).String())
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package pkg
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
+	htmltemplate "html/template"
 	"io"
 	"io/ioutil"
 	"os"
@@ -11,12 +14,22 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+var (
+	// Version 1 of the envsnap result (snapshot) scheme.
+	resultV1 = 1
+)
+
 var common ResultCommon
 
+// defaultCollapseLines is the default number of lines of command output
+// which are shown in GitHub-flavored markdown before the output is collapsed.
+const defaultCollapseLines = 20
+
 func init() {
 	common = ResultCommon{
-		CodeQuote: "`",
-		CodeFence: "```",
+		CodeQuote:     "`",
+		CodeFence:     "```",
+		CollapseLines: defaultCollapseLines,
 	}
 }
 
@@ -27,6 +40,132 @@ type ResultCommon struct {
 	// TODO move somewhere outside of interface
 	CodeQuote string
 	CodeFence string
+
+	// CollapseLines is the number of lines of command output which are shown
+	// in GitHub-flavored markdown output before it is collapsed. If it is 0,
+	// output is never collapsed.
+	CollapseLines int
+
+	// Meta holds the display metadata of the rendered items which have any,
+	// keyed by the item's key in the result.
+	Meta map[string]ItemMeta
+}
+
+// ItemMeta holds the metadata of a config item which determines how its
+// result is displayed.
+type ItemMeta struct {
+	Label       string `json:"label,omitempty" yaml:"label,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Redact      bool   `json:"redact,omitempty" yaml:"redact,omitempty"`
+}
+
+// addMeta records the display metadata of an item under the given key, if
+// the item has any.
+func (c *ResultCommon) addMeta(key string, item Item) {
+	if !item.hasMeta() {
+		return
+	}
+	if c.Meta == nil {
+		c.Meta = map[string]ItemMeta{}
+	}
+	c.Meta[key] = item.meta()
+}
+
+// Label gets the label to display for the item with the given key, or the
+// fallback if the item has no label.
+func (c ResultCommon) Label(key, fallback string) string {
+	if label := c.Meta[key].Label; label != "" {
+		return label
+	}
+	return fallback
+}
+
+// Describe gets the description of the item with the given key, formatted
+// to follow the item in the output, or an empty string if it has none.
+func (c ResultCommon) Describe(key string) string {
+	if desc := c.Meta[key].Description; desc != "" {
+		return " (" + desc + ")"
+	}
+	return ""
+}
+
+// Description gets the description of the item with the given key, or an
+// empty string if it has none.
+func (c ResultCommon) Description(key string) string {
+	return c.Meta[key].Description
+}
+
+// Comment gets the label and description of the item with the given key,
+// for outputs which display them as a comment next to the item, or an empty
+// string if it has neither.
+func (c ResultCommon) Comment(key string) string {
+	meta := c.Meta[key]
+	if meta.Label == "" {
+		return meta.Description
+	}
+	return meta.Label + c.Describe(key)
+}
+
+// Shown checks whether the item with the given key is displayed.
+func (c ResultCommon) Shown(key string) bool {
+	return !c.Meta[key].Hidden
+}
+
+// hidden gets the keys of the items which are hidden.
+func (c ResultCommon) hidden() []string {
+	var keys []string
+	for key, meta := range c.Meta {
+		if meta.Hidden {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// visibleMeta gets the metadata of the items which are not hidden, or nil if
+// none of them have any.
+func (c ResultCommon) visibleMeta() map[string]ItemMeta {
+	var meta map[string]ItemMeta
+	for key, m := range c.Meta {
+		if m.Hidden {
+			continue
+		}
+		if meta == nil {
+			meta = map[string]ItemMeta{}
+		}
+		meta[key] = m
+	}
+	return meta
+}
+
+// Cell formats a value for a cell of a GitHub-flavored markdown table. HTML
+// is escaped, and pipes and newlines, which would otherwise break the table,
+// are escaped and replaced with line breaks respectively.
+func (c ResultCommon) Cell(value interface{}) string {
+	cell := html.EscapeString(strings.TrimSpace(fmt.Sprint(value)))
+	cell = strings.Replace(cell, "|", "\\|", -1)
+	return strings.Replace(cell, "\n", "<br>", -1)
+}
+
+// Output trims the trailing newlines from command output, so that it can be
+// placed in a code block without adding blank lines to the end of it.
+func (c ResultCommon) Output(output string) string {
+	return strings.TrimRight(output, "\n")
+}
+
+// Lines counts the lines of the given output.
+func (c ResultCommon) Lines(output string) int {
+	output = c.Output(output)
+	if output == "" {
+		return 0
+	}
+	return strings.Count(output, "\n") + 1
+}
+
+// Collapse checks whether the given output is long enough to be collapsed.
+func (c ResultCommon) Collapse(output string) bool {
+	return c.CollapseLines > 0 && c.Lines(output) > c.CollapseLines
 }
 
 // Result defines an interface for rendered configurations which allows
@@ -36,6 +175,8 @@ type Result interface {
 
 	Markdown() ([]byte, error)
 	Plaintext() ([]byte, error)
+	HTML() ([]byte, error)
+	GFM() ([]byte, error)
 	YAML() ([]byte, error)
 	JSON() ([]byte, error)
 }
@@ -45,19 +186,96 @@ type Result interface {
 type EnvsnapResult interface {
 	Results() []Result
 	String(format string) (string, error)
+	Template(text string) (string, error)
 	Write(file, format string) error
 	Print(format string) error
 }
 
+// ConfigSource describes a remote config which a snapshot was rendered from,
+// so that the snapshot records exactly which config produced it.
+type ConfigSource struct {
+	Ref    string `json:"ref" yaml:"ref"`
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Digest string `json:"digest" yaml:"digest"`
+
+	// SignedBy is the ID of the key which signed the config, if signatures
+	// of remote configs are verified.
+	SignedBy string `json:"signed_by,omitempty" yaml:"signed_by,omitempty"`
+
+	// exec holds the commands which the config runs. It is used to ask
+	// the user to trust the config before running them.
+	exec []string
+}
+
+// VersionedResult is an intermediary struct which is used to load the
+// version information from a serialized snapshot. This allows envsnap to
+// determine the version of the snapshot, and thus the correct struct to
+// load the snapshot into.
+type VersionedResult struct {
+	Version *int `json:"version" yaml:"version"`
+}
+
+// LoadResult loads a snapshot which was previously rendered to file in
+// either JSON or YAML format, rebuilding the typed result for each source.
+func LoadResult(path string) (EnvsnapResult, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, ErrNoSnapshot
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML, but the two formats do not serialize all
+	// results with the same structure (e.g. the environment result is inlined
+	// for YAML), so the format needs to be known to decode the snapshot.
+	unmarshal := yaml.Unmarshal
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		unmarshal = json.Unmarshal
+	}
+
+	v := &VersionedResult{}
+	if err := unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	if v.Version == nil {
+		return nil, ErrNoSnapshotVersion
+	}
+
+	switch *v.Version {
+	case resultV1:
+		raw := v1ResultData{}
+		if err := unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		res := raw.toResult()
+		return &res, nil
+
+	default:
+		return nil, ErrInvalidSnapshotVersion
+	}
+}
+
 // V1EnvsnapResult contains the results for all sources specified by version 1
 // of the envsnap configuration, as defined in V1EnvsnapConfig.
 type V1EnvsnapResult struct {
+	Version int            `json:"version" yaml:"version"`
+	Meta    *SnapshotMeta  `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Config  []ConfigSource `json:"config,omitempty" yaml:"config,omitempty"`
+
 	Environment Result `json:"environment,omitempty" yaml:"environment,omitempty"`
 	Exec        Result `json:"exec,omitempty" yaml:"exec,omitempty"`
 	Golang      Result `json:"golang,omitempty" yaml:"golang,omitempty"`
 	Python      Result `json:"python,omitempty" yaml:"python,omitempty"`
 	System      Result `json:"system,omitempty" yaml:"system,omitempty"`
 
+	// Items holds the metadata of the items in each source which have any,
+	// keyed by the key of the source and then by the key of the item, so
+	// that it is kept when the snapshot is loaded again. It is only set on
+	// the copy of the result which is serialized (see visible).
+	Items map[string]map[string]ItemMeta `json:"items,omitempty" yaml:"items,omitempty"`
+
 	out io.Writer
 }
 
@@ -65,8 +283,152 @@ type V1EnvsnapResult struct {
 // the default `out` to stdout.
 func NewV1EnvsnapResult() V1EnvsnapResult {
 	return V1EnvsnapResult{
-		out: os.Stdout,
+		Version: resultV1,
+		out:     os.Stdout,
+	}
+}
+
+// v1ResultData mirrors V1EnvsnapResult using the concrete result type for
+// each source, which allows a serialized snapshot to be decoded back into
+// its typed results.
+type v1ResultData struct {
+	Version     int            `json:"version" yaml:"version"`
+	Meta        *SnapshotMeta  `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Config      []ConfigSource `json:"config,omitempty" yaml:"config,omitempty"`
+	Environment *EnvResult     `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Exec        *ExecResult    `json:"exec,omitempty" yaml:"exec,omitempty"`
+	Golang      *GolangResult  `json:"golang,omitempty" yaml:"golang,omitempty"`
+	Python      *PythonResult  `json:"python,omitempty" yaml:"python,omitempty"`
+	System      *SystemResult  `json:"system,omitempty" yaml:"system,omitempty"`
+
+	Items map[string]map[string]ItemMeta `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// toResult converts the decoded data into a V1EnvsnapResult. Sources which
+// were not present in the serialized snapshot are left unset. The metadata
+// of the items in each source is restored from Items.
+func (d v1ResultData) toResult() V1EnvsnapResult {
+	res := NewV1EnvsnapResult()
+	res.Config = d.Config
+	if d.Meta != nil {
+		meta := *d.Meta
+		meta.ResultCommon = common
+		res.Meta = &meta
+	}
+	if d.Environment != nil {
+		env := NewEnvResult()
+		env.Meta = d.Items["environment"]
+		for k, v := range d.Environment.Env {
+			env.Env[k] = v
+		}
+		res.Environment = env
+	}
+	if d.Exec != nil {
+		exec := NewExecResult()
+		exec.Meta = d.Items["exec"]
+		for k, v := range d.Exec.Exec {
+			exec.Exec[k] = v
+		}
+		res.Exec = exec
+	}
+	if d.Golang != nil {
+		golang := *d.Golang
+		golang.ResultCommon = common
+		golang.Meta = d.Items["golang"]
+		res.Golang = golang
+	}
+	if d.Python != nil {
+		python := NewPythonResult()
+		python.Meta = d.Items["python"]
+		python.Version = d.Python.Version
+		python.VersionPy2 = d.Python.VersionPy2
+		python.VersionPy3 = d.Python.VersionPy3
+		for k, v := range d.Python.Deps {
+			python.Deps[k] = v
+		}
+		res.Python = python
+	}
+	if d.System != nil {
+		system := *d.System
+		system.ResultCommon = common
+		system.Meta = d.Items["system"]
+		res.System = system
+	}
+	return res
+}
+
+// data converts the result into its decoded form, which holds the concrete
+// result type for each source. Sources which are not set or which are empty
+// are left nil.
+func (r *V1EnvsnapResult) data() v1ResultData {
+	d := v1ResultData{
+		Version: r.Version,
+		Meta:    r.Meta,
+		Config:  r.Config,
+		Items:   r.Items,
+	}
+	if res, ok := r.Environment.(EnvResult); ok && !res.IsEmpty() {
+		d.Environment = &res
+	}
+	if res, ok := r.Exec.(ExecResult); ok && !res.IsEmpty() {
+		d.Exec = &res
+	}
+	if res, ok := r.Golang.(GolangResult); ok && !res.IsEmpty() {
+		d.Golang = &res
+	}
+	if res, ok := r.Python.(PythonResult); ok && !res.IsEmpty() {
+		d.Python = &res
+	}
+	if res, ok := r.System.(SystemResult); ok && !res.IsEmpty() {
+		d.System = &res
+	}
+	return d
+}
+
+// visible gets a copy of the result for the formats which serialize it,
+// rather than display it (e.g. JSON and TOML), and for user templates. Those
+// formats can not check whether an item is shown, so the items which are
+// hidden are left out, and the metadata of the other items is recorded in
+// Items.
+func (r *V1EnvsnapResult) visible() *V1EnvsnapResult {
+	v := *r
+	v.Items = nil
+	add := func(source string, meta map[string]ItemMeta) {
+		if len(meta) == 0 {
+			return
+		}
+		if v.Items == nil {
+			v.Items = map[string]map[string]ItemMeta{}
+		}
+		v.Items[source] = meta
+	}
+
+	if res, ok := r.Environment.(EnvResult); ok {
+		res = res.withoutHidden()
+		v.Environment = res
+		add("environment", res.Meta)
+	}
+	if res, ok := r.Exec.(ExecResult); ok {
+		res = res.withoutHidden()
+		v.Exec = res
+		add("exec", res.Meta)
+	}
+	if res, ok := r.Golang.(GolangResult); ok {
+		res = res.withoutHidden()
+		v.Golang = res
+		add("golang", res.Meta)
+	}
+	if res, ok := r.Python.(PythonResult); ok {
+		res = res.withoutHidden()
+		v.Python = res
+		add("python", res.Meta)
 	}
+	if res, ok := r.System.(SystemResult); ok {
+		res = res.withoutHidden()
+		v.System = res
+		add("system", res.Meta)
+	}
+	return &v
 }
 
 // Results returns all of the component source results in the order in which
@@ -81,13 +443,23 @@ func (r *V1EnvsnapResult) Results() []Result {
 	}
 }
 
+// sections returns the snapshot metadata, if the result has any, followed by
+// all of the component source results, in the order in which they should be
+// rendered.
+func (r *V1EnvsnapResult) sections() []Result {
+	if r.Meta == nil {
+		return r.Results()
+	}
+	return append([]Result{*r.Meta}, r.Results()...)
+}
+
 // String renders the result into a string based on the given format option.
 // If the provided format is not supported, this returns an error.
 func (r *V1EnvsnapResult) String(format string) (string, error) {
 	switch format {
 	case "markdown", "md":
 		var parts []string
-		for _, res := range r.Results() {
+		for _, res := range r.sections() {
 			if res == nil || res.IsEmpty() {
 				continue
 			}
@@ -101,7 +473,7 @@ func (r *V1EnvsnapResult) String(format string) (string, error) {
 
 	case "plaintext", "txt":
 		var parts []string
-		for _, res := range r.Results() {
+		for _, res := range r.sections() {
 			if res == nil || res.IsEmpty() {
 				continue
 			}
@@ -113,15 +485,78 @@ func (r *V1EnvsnapResult) String(format string) (string, error) {
 		}
 		return strings.Join(parts, "\n"), nil
 
+	case "gfm":
+		var parts []string
+		for _, res := range r.sections() {
+			if res == nil || res.IsEmpty() {
+				continue
+			}
+			data, err := res.GFM()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, string(data))
+		}
+		return "#### Environment\n\n" + strings.Join(parts, "\n"), nil
+
+	case "html":
+		var sections []htmltemplate.HTML
+		for _, res := range r.sections() {
+			if res == nil || res.IsEmpty() {
+				continue
+			}
+			data, err := res.HTML()
+			if err != nil {
+				return "", err
+			}
+			// The sections are rendered with html/template, so their content
+			// is already escaped.
+			sections = append(sections, htmltemplate.HTML(data))
+		}
+		t := htmltemplate.Must(htmltemplate.New("html").Parse(HTMLDocumentTemplate))
+
+		buffer := bytes.Buffer{}
+		if err := t.Execute(&buffer, struct{ Sections []htmltemplate.HTML }{sections}); err != nil {
+			return "", err
+		}
+		return buffer.String(), nil
+
+	case "toml":
+		return toTOML(r.Version, r.visible().flatten()), nil
+
+	case "ini":
+		return toINI(r.Version, r.visible().flatten()), nil
+
+	case "env", "dotenv":
+		return toEnv(r.visible().flatten()), nil
+
+	case "cyclonedx":
+		data, err := marshalJSON(r.visible().cycloneDX(), JSONOptions{Indent: jsonOptions.Indent})
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+
+	case "spdx":
+		doc, err := r.visible().spdx()
+		if err != nil {
+			return "", err
+		}
+		data, err := marshalJSON(doc, JSONOptions{Indent: jsonOptions.Indent})
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+
 	case "yaml":
-		data, err := yaml.Marshal(r)
+		data, err := yaml.Marshal(r.visible())
 		if err != nil {
 			return "", err
 		}
 		return string(data), nil
 
 	case "json":
-		data, err := json.Marshal(r)
+		data, err := marshalJSON(r.visible(), jsonOptions)
 		if err != nil {
 			return "", err
 		}
@@ -132,14 +567,23 @@ func (r *V1EnvsnapResult) String(format string) (string, error) {
 	}
 }
 
+// Template renders the result with a user-supplied Go template. The template
+// is executed with the typed results of each source, e.g. {{ .System.OS }},
+// where sources which are not in the snapshot are nil. Hidden items are left
+// out (see visible).
+func (r *V1EnvsnapResult) Template(text string) (string, error) {
+	return RenderTemplate(text, r.visible().data())
+}
+
 // Write renders the result into a string based on the provided format and
-// writes that string to the specified file.
+// writes that string to the specified file. The file is written atomically,
+// and its parent directories are created if they do not exist.
 func (r *V1EnvsnapResult) Write(file, format string) error {
 	out, err := r.String(format)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(file, []byte(out), 0644)
+	return writeFileAtomic(file, []byte(out), 0644)
 }
 
 // Print renders the result into a string based on the provided format and
@@ -4,14 +4,36 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func TestResultCommon_Cell(t *testing.T) {
+	var c ResultCommon
+	assert.Equal(t, "12", c.Cell(12))
+	assert.Equal(t, "a\\|b", c.Cell("a|b"))
+	assert.Equal(t, "a<br>b", c.Cell("a\nb\n"))
+	assert.Equal(t, "&lt;b&gt;", c.Cell("<b>"))
+}
+
+func TestResultCommon_Collapse(t *testing.T) {
+	c := ResultCommon{CollapseLines: 2}
+	assert.Equal(t, 0, c.Lines(""))
+	assert.Equal(t, 2, c.Lines("a\nb\n"))
+	assert.False(t, c.Collapse("a\nb\n"))
+	assert.True(t, c.Collapse("a\nb\nc"))
+
+	c.CollapseLines = 0
+	assert.False(t, c.Collapse("a\nb\nc"))
+}
+
 func TestNewV1EnvsnapResult(t *testing.T) {
 	v1 := NewV1EnvsnapResult()
 	assert.Equal(t, os.Stdout, v1.out)
+	assert.Equal(t, 1, v1.Version)
 	assert.Nil(t, v1.Environment)
 	assert.Nil(t, v1.Exec)
 	assert.Nil(t, v1.Golang)
@@ -19,6 +41,24 @@ func TestNewV1EnvsnapResult(t *testing.T) {
 	assert.Nil(t, v1.System)
 }
 
+func TestResultCommon_Meta(t *testing.T) {
+	var c ResultCommon
+	c.addMeta("plain", Item{Value: "plain"})
+	c.addMeta("labeled", Item{Value: "labeled", Label: "Label", Description: "desc"})
+	c.addMeta("hidden", Item{Value: "hidden", Hidden: true})
+	assert.Len(t, c.Meta, 2)
+
+	assert.Equal(t, "plain", c.Label("plain", "plain"))
+	assert.Equal(t, "Label", c.Label("labeled", "labeled"))
+	assert.Equal(t, "", c.Describe("plain"))
+	assert.Equal(t, " (desc)", c.Describe("labeled"))
+	assert.Equal(t, "", c.Comment("plain"))
+	assert.Equal(t, "Label (desc)", c.Comment("labeled"))
+	assert.True(t, c.Shown("plain"))
+	assert.True(t, c.Shown("labeled"))
+	assert.False(t, c.Shown("hidden"))
+}
+
 func TestV1EnvsnapResult_Results(t *testing.T) {
 	v1 := NewV1EnvsnapResult()
 	v1.System = NewSystemResult()
@@ -62,6 +102,35 @@ func TestV1EnvsnapResult_String_Plaintext(t *testing.T) {
 	assert.Equal(t, "System\n------\nos:             testOS\n", data)
 }
 
+func TestV1EnvsnapResult_String_GFM(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	v1 := NewV1EnvsnapResult()
+	v1.System = sys
+
+	data, err := v1.String("gfm")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "#### Environment\n\n<details><summary>System</summary>\n\n| Key | Value |\n| :--- | :--- |\n| os | testOS |\n\n</details>\n", data)
+}
+
+func TestV1EnvsnapResult_String_HTML(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	v1 := NewV1EnvsnapResult()
+	v1.System = sys
+
+	data, err := v1.String("html")
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(data, "<!DOCTYPE html>\n"))
+	assert.Contains(t, data, "<summary>System</summary>\n<table>\n<tr><th>os</th><td>testOS</td></tr>")
+	assert.NotContains(t, data, "<summary>Environment</summary>")
+	assert.NotContains(t, data, "&lt;details")
+}
+
 func TestV1EnvsnapResult_String_JSON(t *testing.T) {
 	sys := NewSystemResult()
 	sys.OS = "testOS"
@@ -72,7 +141,7 @@ func TestV1EnvsnapResult_String_JSON(t *testing.T) {
 	data, err := v1.String("json")
 	assert.NoError(t, err)
 
-	assert.Equal(t, `{"system":{"os":"testOS"}}`, data)
+	assert.Equal(t, `{"version":1,"system":{"os":"testOS"}}`, data)
 }
 
 func TestV1EnvsnapResult_String_YAML(t *testing.T) {
@@ -85,7 +154,58 @@ func TestV1EnvsnapResult_String_YAML(t *testing.T) {
 	data, err := v1.String("yaml")
 	assert.NoError(t, err)
 
-	assert.Equal(t, "system:\n  os: testOS\n", data)
+	assert.Equal(t, "version: 1\nsystem:\n  os: testOS\n", data)
+}
+
+func TestV1EnvsnapResult_String_Config(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	v1 := NewV1EnvsnapResult()
+	v1.Config = []ConfigSource{{Ref: "github.com/foo/bar@v1", Commit: "abc123", Digest: "sha256:def456"}}
+	v1.System = sys
+
+	data, err := v1.String("yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 1\nconfig:\n- ref: github.com/foo/bar@v1\n  commit: abc123\n  digest: sha256:def456\nsystem:\n  os: testOS\n", data)
+
+	data, err = v1.String("json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"config":[{"ref":"github.com/foo/bar@v1","commit":"abc123","digest":"sha256:def456"}],"system":{"os":"testOS"}}`, data)
+
+	// The config sources are not included in human-readable output.
+	data, err = v1.String("md")
+	assert.NoError(t, err)
+	assert.NotContains(t, data, "github.com/foo/bar")
+}
+
+func TestV1EnvsnapResult_String_Meta(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+	meta := SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Hostname: "host"}
+
+	v1 := NewV1EnvsnapResult()
+	v1.Meta = &meta
+	v1.System = sys
+
+	data, err := v1.String("markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, "#### Environment\n\n**Snapshot**\n- _captured at_: 2020-01-02T03:04:05Z\n- _hostname_: host\n\n**System**\n- _os_: testOS\n", data)
+
+	data, err = v1.String("plaintext")
+	assert.NoError(t, err)
+	assert.Equal(t, "Snapshot\n--------\ncaptured at:   2020-01-02T03:04:05Z\nhostname:      host\n\nSystem\n------\nos:             testOS\n", data)
+
+	data, err = v1.String("json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"meta":{"captured_at":"2020-01-02T03:04:05Z","hostname":"host"},"system":{"os":"testOS"}}`, data)
+
+	for _, format := range []string{"gfm", "html"} {
+		data, err = v1.String(format)
+		assert.NoError(t, err)
+		assert.Contains(t, data, "<summary>Snapshot</summary>")
+		assert.True(t, strings.Index(data, "Snapshot") < strings.Index(data, "System"))
+	}
 }
 
 func TestV1EnvsnapResult_String_UnsupportedFmt(t *testing.T) {
@@ -113,7 +233,7 @@ func TestV1EnvsnapResult_Write(t *testing.T) {
 
 	data, err := ioutil.ReadFile(file.Name())
 	assert.NoError(t, err)
-	assert.Equal(t, `{"system":{"os":"testOS"}}`, string(data))
+	assert.Equal(t, `{"version":1,"system":{"os":"testOS"}}`, string(data))
 }
 
 func TestV1EnvsnapResult_Print(t *testing.T) {
@@ -129,5 +249,185 @@ func TestV1EnvsnapResult_Print(t *testing.T) {
 	err := v1.Print("json")
 	assert.NoError(t, err)
 
-	assert.Equal(t, "{\"system\":{\"os\":\"testOS\"}}\n", out.String())
+	assert.Equal(t, "{\"version\":1,\"system\":{\"os\":\"testOS\"}}\n", out.String())
+}
+
+func TestLoadResult_JSON(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`{"version":1,"environment":{"env":{"FOO":"bar"}},"exec":{"exec":{"echo hello":"hello\n"}},"golang":{"version":"go1.13.4"},"python":{"version":"3.8.0","dependencies":{"requests":"2.22.0"}},"system":{"os":"testOS","cpus":4}}`)
+	assert.NoError(t, err)
+
+	res, err := LoadResult(file.Name())
+	assert.NoError(t, err)
+	assert.IsType(t, &V1EnvsnapResult{}, res)
+
+	v1 := res.(*V1EnvsnapResult)
+	assert.Equal(t, 1, v1.Version)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, v1.Environment.(EnvResult).Env)
+	assert.Equal(t, map[string]string{"echo hello": "hello\n"}, v1.Exec.(ExecResult).Exec)
+	assert.Equal(t, "go1.13.4", v1.Golang.(GolangResult).Version)
+	assert.Equal(t, "3.8.0", v1.Python.(PythonResult).Version)
+	assert.Equal(t, map[string]string{"requests": "2.22.0"}, v1.Python.(PythonResult).Deps)
+	assert.Equal(t, "testOS", v1.System.(SystemResult).OS)
+	assert.Equal(t, Count(4), v1.System.(SystemResult).CPUs)
+}
+
+func TestLoadResult_YAML(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("version: 1\nenvironment:\n  FOO: bar\nsystem:\n  os: testOS\n")
+	assert.NoError(t, err)
+
+	res, err := LoadResult(file.Name())
+	assert.NoError(t, err)
+	assert.IsType(t, &V1EnvsnapResult{}, res)
+
+	v1 := res.(*V1EnvsnapResult)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, v1.Environment.(EnvResult).Env)
+	assert.Equal(t, "testOS", v1.System.(SystemResult).OS)
+	assert.Nil(t, v1.Exec)
+	assert.Nil(t, v1.Golang)
+	assert.Nil(t, v1.Python)
+
+	// The loaded results should be renderable to other formats.
+	data, err := res.String("markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, "#### Environment\n\n**System**\n- _os_: testOS\n\n**Environment**\n```\nFOO=bar\n```\n", data)
+}
+
+func TestLoadResult_RoundTrip(t *testing.T) {
+	env := NewEnvResult()
+	env.Env["FOO"] = "bar"
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+
+	v1 := NewV1EnvsnapResult()
+	v1.Config = []ConfigSource{{Ref: "github.com/foo/bar@v1", Commit: "abc123", Digest: "sha256:def456"}}
+	v1.Meta = &SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Version: "1.2.3", Hostname: "host"}
+	v1.Environment = env
+	v1.System = sys
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			file, err := ioutil.TempFile("", "envsnap-test")
+			assert.NoError(t, err)
+			defer os.Remove(file.Name())
+
+			err = v1.Write(file.Name(), format)
+			assert.NoError(t, err)
+
+			res, err := LoadResult(file.Name())
+			assert.NoError(t, err)
+
+			expected, err := v1.String(format)
+			assert.NoError(t, err)
+			actual, err := res.String(format)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestLoadResult_ItemMeta(t *testing.T) {
+	env := NewEnvResult()
+	env.Env["FOO"] = "bar"
+	env.addMeta("FOO", Item{Value: "FOO", Label: "Foo", Description: "a test var"})
+
+	v1 := NewV1EnvsnapResult()
+	v1.Environment = env
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			file, err := ioutil.TempFile("", "envsnap-test")
+			assert.NoError(t, err)
+			defer os.Remove(file.Name())
+
+			err = v1.Write(file.Name(), format)
+			assert.NoError(t, err)
+
+			res, err := LoadResult(file.Name())
+			assert.NoError(t, err)
+
+			// The labels and descriptions of the items should survive a
+			// convert.
+			expected, err := v1.String("markdown")
+			assert.NoError(t, err)
+			actual, err := res.String("markdown")
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			assert.Contains(t, actual, "Foo (a test var)")
+		})
+	}
+}
+
+func TestV1EnvsnapResult_String_Hidden(t *testing.T) {
+	env := NewEnvResult()
+	env.Env["FOO"] = "bar"
+	env.Env["SECRET"] = "hunter2"
+	env.addMeta("SECRET", Item{Value: "SECRET", Hidden: true})
+	sys := NewSystemResult()
+	sys.OS = "testOS"
+	sys.Arch = "testArch"
+	sys.addMeta("arch", Item{Value: "arch", Hidden: true})
+
+	v1 := NewV1EnvsnapResult()
+	v1.Environment = env
+	v1.System = sys
+
+	for _, format := range []string{"json", "yaml", "toml", "ini", "env"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := v1.String(format)
+			assert.NoError(t, err)
+			assert.Contains(t, data, "FOO")
+			assert.Contains(t, data, "testOS")
+			assert.NotContains(t, data, "SECRET")
+			assert.NotContains(t, data, "hunter2")
+			assert.NotContains(t, data, "testArch")
+		})
+	}
+
+	data, err := v1.Template("{{ .Environment.Env }} {{ .System.Arch }}")
+	assert.NoError(t, err)
+	assert.Equal(t, "map[FOO:bar] ", data)
+
+	// Hiding items must not change the result itself.
+	assert.Equal(t, "hunter2", v1.Environment.(EnvResult).Env["SECRET"])
+	assert.Equal(t, "testArch", v1.System.(SystemResult).Arch)
+}
+
+func TestLoadResult_NoFile(t *testing.T) {
+	res, err := LoadResult("this-file-does-not-exist.json")
+	assert.Nil(t, res)
+	assert.Equal(t, ErrNoSnapshot, err)
+}
+
+func TestLoadResult_NoVersion(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`{"system":{"os":"testOS"}}`)
+	assert.NoError(t, err)
+
+	res, err := LoadResult(file.Name())
+	assert.Nil(t, res)
+	assert.Equal(t, ErrNoSnapshotVersion, err)
+}
+
+func TestLoadResult_InvalidVersion(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("version: 99\n")
+	assert.NoError(t, err)
+
+	res, err := LoadResult(file.Name())
+	assert.Nil(t, res)
+	assert.Equal(t, ErrInvalidSnapshotVersion, err)
 }
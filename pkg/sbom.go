@@ -0,0 +1,335 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The versions of the SBOM specifications which snapshots are exported as.
+const (
+	cycloneDXSpecVersion = "1.4"
+	spdxVersion          = "SPDX-2.3"
+)
+
+// sbomComponent is a dependency of the environment, which is exported as a
+// component of an SBOM.
+type sbomComponent struct {
+	Name    string
+	Version string
+	PURL    string
+}
+
+// sbomProperty is a value describing the environment, which is exported as
+// metadata of an SBOM.
+type sbomProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// purl formats a package URL (https://github.com/package-url/purl-spec) for
+// the package of the given type. The version is left out if it is empty.
+func purl(typ, name, version string) string {
+	p := "pkg:" + typ + "/" + purlEscape(name)
+	if version != "" {
+		p += "@" + purlEscape(version)
+	}
+	return p
+}
+
+// purlEscape percent-encodes a component of a package URL.
+func purlEscape(s string) string {
+	return strings.Replace(url.PathEscape(s), "+", "%2B", -1)
+}
+
+// pypiName normalizes the name of a Python package for its package URL: it
+// is lower-cased, and underscores are replaced with dashes.
+func pypiName(name string) string {
+	return strings.Replace(strings.ToLower(name), "_", "-", -1)
+}
+
+// sbomComponents gets the dependencies in the snapshot, sorted by their
+// package URL. The dependencies are:
+//
+//   - the Go standard library, as "pkg:golang/stdlib@v<version>"
+//   - the Python dependencies, as "pkg:pypi/<name>@<version>"
+//
+// Dependencies which were not found are left out. Redacted versions are left
+// out of the component, so that only the package is listed. Python packages
+// whose names normalize to the same package URL (e.g. "Foo_Bar" and
+// "foo-bar") are listed once, under the first of their names.
+func (r *V1EnvsnapResult) sbomComponents() []sbomComponent {
+	d := r.data()
+
+	version := func(v string) string {
+		if v == redactedValue {
+			return ""
+		}
+		return v
+	}
+
+	var components []sbomComponent
+	if d.Golang != nil && d.Golang.Version != "" {
+		v := version(d.Golang.Version)
+		if v != "" {
+			v = "v" + strings.TrimPrefix(v, "go")
+		}
+		components = append(components, sbomComponent{
+			Name:    "stdlib",
+			Version: v,
+			PURL:    purl("golang", "stdlib", v),
+		})
+	}
+	if d.Python != nil {
+		var names []string
+		for name := range d.Python.Deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		seen := map[string]bool{}
+		for _, name := range names {
+			v := d.Python.Deps[name]
+			if v == "" {
+				continue
+			}
+			p := purl("pypi", pypiName(name), version(v))
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			components = append(components, sbomComponent{
+				Name:    name,
+				Version: version(v),
+				PURL:    p,
+			})
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].PURL < components[j].PURL
+	})
+	return components
+}
+
+// sbomProperties gets the values describing the environment in which the
+// snapshot was captured. They are the values of the flattened snapshot (see
+// flatten), named "envsnap:<section>:<key>", except for the dependencies,
+// which are exported as components.
+func (r *V1EnvsnapResult) sbomProperties() []sbomProperty {
+	var properties []sbomProperty
+	for _, s := range r.flatten() {
+		if strings.Join(s.Path, ".") == "python.dependencies" {
+			continue
+		}
+		prefix := "envsnap:" + strings.Join(s.Path, ":") + ":"
+		for _, v := range s.Values {
+			properties = append(properties, sbomProperty{
+				Name:  prefix + v.Key,
+				Value: fmt.Sprint(v.Value),
+			})
+		}
+	}
+	return properties
+}
+
+// sbomTimestamp gets the time to record as the creation time of an SBOM:
+// the capture time of the snapshot, if it has metadata, or the current time.
+func (r *V1EnvsnapResult) sbomTimestamp() time.Time {
+	if r.Meta != nil && !r.Meta.IsEmpty() {
+		return r.Meta.CapturedAt.UTC()
+	}
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// cdxBOM is a CycloneDX bill of materials.
+type cdxBOM struct {
+	BOMFormat   string         `json:"bomFormat"`
+	SpecVersion string         `json:"specVersion"`
+	Version     int            `json:"version"`
+	Metadata    cdxMetadata    `json:"metadata"`
+	Components  []cdxComponent `json:"components"`
+}
+
+// cdxMetadata is the metadata of a CycloneDX bill of materials.
+type cdxMetadata struct {
+	Timestamp  string         `json:"timestamp,omitempty"`
+	Tools      []cdxTool      `json:"tools"`
+	Properties []sbomProperty `json:"properties,omitempty"`
+}
+
+// cdxTool is a tool which created a CycloneDX bill of materials.
+type cdxTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// cdxComponent is a component of a CycloneDX bill of materials.
+type cdxComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl"`
+}
+
+// cycloneDX exports the dependencies of the snapshot as a CycloneDX bill of
+// materials, with the environment recorded as properties of its metadata.
+//
+// The timestamp is only set if the snapshot has metadata, so that exporting
+// the same snapshot twice gives the same document.
+func (r *V1EnvsnapResult) cycloneDX() cdxBOM {
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: cdxMetadata{
+			Tools:      []cdxTool{{Name: "envsnap", Version: Version}},
+			Properties: r.sbomProperties(),
+		},
+		Components: []cdxComponent{},
+	}
+	if r.Meta != nil && !r.Meta.IsEmpty() {
+		bom.Metadata.Timestamp = r.sbomTimestamp().Format(time.RFC3339)
+	}
+	for _, c := range r.sbomComponents() {
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:    "library",
+			BOMRef:  c.PURL,
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+		})
+	}
+	return bom
+}
+
+// spdxDocument is an SPDX document.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	Comment           string             `json:"comment,omitempty"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+// spdxCreationInfo describes how an SPDX document was created.
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// spdxPackage is a package described by an SPDX document.
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+// spdxExternalRef is a reference from an SPDX package to an external source
+// of information about it.
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// spdxRelationship is a relationship between elements of an SPDX document.
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxInvalid matches the runs of characters which are not allowed in SPDX
+// identifiers.
+var spdxInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// spdxID formats the SPDX identifier of a package from its package URL.
+func spdxID(purl string) string {
+	id := strings.TrimPrefix(purl, "pkg:")
+	return "SPDXRef-Package-" + strings.Trim(spdxInvalid.ReplaceAllString(id, "-"), "-")
+}
+
+// spdx exports the dependencies of the snapshot as an SPDX document, with
+// the environment recorded in the comment of the document, one
+// "<name>=<value>" property per line.
+//
+// SPDX requires a creation time, which is the capture time of the snapshot
+// if it has metadata, or the current time otherwise. The document namespace
+// is derived from the content of the document, so that it is unique to it.
+func (r *V1EnvsnapResult) spdx() (spdxDocument, error) {
+	creator := "Tool: envsnap"
+	if Version != "" {
+		creator += "-" + Version
+	}
+
+	var comment []string
+	for _, p := range r.sbomProperties() {
+		value := p.Value
+		if strings.ContainsAny(value, "\r\n") {
+			value = strconv.Quote(value)
+		}
+		comment = append(comment, p.Name+"="+value)
+	}
+
+	doc := spdxDocument{
+		SPDXVersion: spdxVersion,
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        "envsnap",
+		Comment:     strings.Join(comment, "\n"),
+		CreationInfo: spdxCreationInfo{
+			Created:  r.sbomTimestamp().Format(time.RFC3339),
+			Creators: []string{creator},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+	ids := map[string]int{}
+	for _, c := range r.sbomComponents() {
+		// Identifiers are derived from the package URLs, which may map to
+		// the same identifier once invalid characters are replaced, so any
+		// repeated identifier is made unique with a suffix.
+		id := spdxID(c.PURL)
+		if ids[id]++; ids[id] > 1 {
+			id += "-" + strconv.Itoa(ids[id])
+		}
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             c.Name,
+			SPDXID:           id,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  c.PURL,
+			}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      doc.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return spdxDocument{}, err
+	}
+	doc.DocumentNamespace = fmt.Sprintf("https://spdx.org/spdxdocs/envsnap-%x", sha256.Sum256(data))
+	return doc, nil
+}
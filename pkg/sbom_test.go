@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sbomTestResult creates a result with dependencies and environment values
+// for the SBOM outputs.
+func sbomTestResult() V1EnvsnapResult {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+
+	golang := NewGolangResult()
+	golang.Version = "go1.13.4"
+
+	python := NewPythonResult()
+	python.Version = "3.8.0"
+	python.Deps["requests"] = "2.22.0"
+	python.Deps["Typing_Extensions"] = "3.7.4+local"
+	python.Deps["missing"] = ""
+	python.Deps["secret"] = redactedValue
+
+	res := NewV1EnvsnapResult()
+	res.System = sys
+	res.Golang = golang
+	res.Python = python
+	return res
+}
+
+func TestPurl(t *testing.T) {
+	assert.Equal(t, "pkg:pypi/requests@2.22.0", purl("pypi", "requests", "2.22.0"))
+	assert.Equal(t, "pkg:pypi/requests", purl("pypi", "requests", ""))
+	assert.Equal(t, "pkg:pypi/foo@1.0%2Blocal", purl("pypi", "foo", "1.0+local"))
+	assert.Equal(t, "pkg:golang/stdlib@v1.13.4", purl("golang", "stdlib", "v1.13.4"))
+}
+
+func TestPypiName(t *testing.T) {
+	assert.Equal(t, "typing-extensions", pypiName("Typing_Extensions"))
+}
+
+func TestSpdxID(t *testing.T) {
+	assert.Equal(t, "SPDXRef-Package-pypi-foo-1.0-2Blocal", spdxID("pkg:pypi/foo@1.0%2Blocal"))
+}
+
+func TestV1EnvsnapResult_sbomComponents(t *testing.T) {
+	res := sbomTestResult()
+
+	assert.Equal(t, []sbomComponent{
+		{Name: "stdlib", Version: "v1.13.4", PURL: "pkg:golang/stdlib@v1.13.4"},
+		{Name: "requests", Version: "2.22.0", PURL: "pkg:pypi/requests@2.22.0"},
+		{Name: "secret", PURL: "pkg:pypi/secret"},
+		{Name: "Typing_Extensions", Version: "3.7.4+local", PURL: "pkg:pypi/typing-extensions@3.7.4%2Blocal"},
+	}, res.sbomComponents())
+}
+
+func TestV1EnvsnapResult_sbomComponents_Duplicates(t *testing.T) {
+	python := NewPythonResult()
+	python.Deps["foo-bar"] = "1.0"
+	python.Deps["Foo_Bar"] = "1.0"
+	python.Deps["foo_bar"] = "2.0"
+
+	res := NewV1EnvsnapResult()
+	res.Python = python
+
+	// Packages with the same package URL are only listed once.
+	assert.Equal(t, []sbomComponent{
+		{Name: "Foo_Bar", Version: "1.0", PURL: "pkg:pypi/foo-bar@1.0"},
+		{Name: "foo_bar", Version: "2.0", PURL: "pkg:pypi/foo-bar@2.0"},
+	}, res.sbomComponents())
+
+	data, err := res.String("cyclonedx")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(data, `"bom-ref":"pkg:pypi/foo-bar@1.0"`))
+}
+
+func TestV1EnvsnapResult_sbomProperties(t *testing.T) {
+	res := sbomTestResult()
+
+	assert.Equal(t, []sbomProperty{
+		{Name: "envsnap:system:os", Value: "linux"},
+		{Name: "envsnap:python:version", Value: "3.8.0"},
+		{Name: "envsnap:golang:version", Value: "go1.13.4"},
+	}, res.sbomProperties())
+}
+
+func TestV1EnvsnapResult_String_CycloneDX(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+	Version = "1.2.3"
+
+	res := sbomTestResult()
+	res.Python = nil
+	res.Meta = &SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	data, err := res.String("cyclonedx")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"bomFormat":"CycloneDX","specVersion":"1.4","version":1,`+
+		`"metadata":{"timestamp":"2020-01-02T03:04:05Z","tools":[{"name":"envsnap","version":"1.2.3"}],`+
+		`"properties":[{"name":"envsnap:meta:captured_at","value":"2020-01-02T03:04:05Z"},{"name":"envsnap:system:os","value":"linux"},{"name":"envsnap:golang:version","value":"go1.13.4"}]},`+
+		`"components":[{"type":"library","bom-ref":"pkg:golang/stdlib@v1.13.4","name":"stdlib","version":"v1.13.4","purl":"pkg:golang/stdlib@v1.13.4"}]}`, data)
+}
+
+func TestV1EnvsnapResult_String_CycloneDX_Empty(t *testing.T) {
+	res := NewV1EnvsnapResult()
+
+	data, err := res.String("cyclonedx")
+	assert.NoError(t, err)
+
+	var bom map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &bom))
+	assert.Equal(t, []interface{}{}, bom["components"])
+	assert.NotContains(t, bom["metadata"], "timestamp")
+}
+
+func TestV1EnvsnapResult_spdx(t *testing.T) {
+	res := sbomTestResult()
+	res.Meta = &SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	doc, err := res.spdx()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "SPDXRef-DOCUMENT", doc.SPDXID)
+	assert.Equal(t, "2020-01-02T03:04:05Z", doc.CreationInfo.Created)
+	assert.True(t, strings.HasPrefix(doc.DocumentNamespace, "https://spdx.org/spdxdocs/envsnap-"))
+	assert.Equal(t, "envsnap:meta:captured_at=2020-01-02T03:04:05Z\nenvsnap:system:os=linux\nenvsnap:python:version=3.8.0\nenvsnap:golang:version=go1.13.4", doc.Comment)
+
+	assert.Len(t, doc.Packages, 4)
+	assert.Equal(t, spdxPackage{
+		Name:             "requests",
+		SPDXID:           "SPDXRef-Package-pypi-requests-2.22.0",
+		VersionInfo:      "2.22.0",
+		DownloadLocation: "NOASSERTION",
+		ExternalRefs: []spdxExternalRef{
+			{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: "pkg:pypi/requests@2.22.0"},
+		},
+	}, doc.Packages[1])
+
+	assert.Len(t, doc.Relationships, 4)
+	assert.Equal(t, spdxRelationship{
+		SPDXElementID:      "SPDXRef-DOCUMENT",
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: "SPDXRef-Package-golang-stdlib-v1.13.4",
+	}, doc.Relationships[0])
+
+	// The namespace is derived from the content of the document.
+	other, err := res.spdx()
+	assert.NoError(t, err)
+	assert.Equal(t, doc.DocumentNamespace, other.DocumentNamespace)
+
+	res.Python.(PythonResult).Deps["requests"] = "2.23.0"
+	other, err = res.spdx()
+	assert.NoError(t, err)
+	assert.NotEqual(t, doc.DocumentNamespace, other.DocumentNamespace)
+}
+
+func TestV1EnvsnapResult_spdx_UniqueIDs(t *testing.T) {
+	python := NewPythonResult()
+	python.Deps["a"] = "1.0"
+	python.Deps["a-1.0"] = redactedValue
+
+	res := NewV1EnvsnapResult()
+	res.Python = python
+
+	// Both package URLs give the same identifier once invalid characters
+	// are replaced.
+	doc, err := res.spdx()
+	assert.NoError(t, err)
+	assert.Len(t, doc.Packages, 2)
+	assert.Equal(t, "SPDXRef-Package-pypi-a-1.0", doc.Packages[0].SPDXID)
+	assert.Equal(t, "SPDXRef-Package-pypi-a-1.0-2", doc.Packages[1].SPDXID)
+	assert.Equal(t, "SPDXRef-Package-pypi-a-1.0-2", doc.Relationships[1].RelatedSPDXElement)
+}
+
+func TestV1EnvsnapResult_spdx_QuotesMultiline(t *testing.T) {
+	exec := NewExecResult()
+	exec.Exec["uname"] = "a\nb\n"
+
+	res := NewV1EnvsnapResult()
+	res.Exec = exec
+
+	doc, err := res.spdx()
+	assert.NoError(t, err)
+	assert.Equal(t, `envsnap:exec:uname="a\nb"`, doc.Comment)
+}
@@ -0,0 +1,201 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaURL is the location of the published JSON Schema for the envsnap
+// configuration. It is generated from the configuration structs with
+// `envsnap schema` (see the Makefile "schema" target).
+const schemaURL = "https://raw.githubusercontent.com/edaniszewski/envsnap/master/envsnap.schema.json"
+
+// configDescriptions maps config paths to the description of that option,
+// which editors display when completing the config.
+var configDescriptions = map[string]string{
+	"version":                      "The version of the envsnap configuration scheme.",
+	"extends":                      "A list of configs which this config extends, given as paths relative to this config or as remote references, e.g. 'github.com/<owner>/<repo>[//<path>][@<ref>]'.",
+	"vars":                         "Variables which can be used in config values as '${name}' or '{{ .Vars.name }}'. Configs are only interpolated if they, or a config they extend, define vars. Values may use environment variables and built-ins such as '{{ .OS }}' and '{{ .Root }}'.",
+	"template":                     "The path of a Go template to render snapshots with, relative to this config or as a remote reference, instead of one of the built-in output formats.",
+	"environment":                  "Render information found in environment variables.",
+	"environment.variables":        "A list of environment variable names whose values are rendered.",
+	"exec":                         "Render information from executing arbitrary commands.",
+	"exec.run":                     "A list of commands to run, the outputs of which are collected and rendered.",
+	"go":                           "Render information about the local Golang installation.",
+	"go.core":                      "A list of core Golang data to render.",
+	"python":                       "Render information about the local Python installation.",
+	"python.core":                  "A list of core Python data to render.",
+	"python.dependencies":          "Python package dependencies to render.",
+	"python.dependencies.packages": "A list of Python packages describing a project's dependencies. The installed version for each dependency is rendered.",
+	"system":                       "Render information about the system.",
+	"system.core":                  "A list of core system data to render.",
+	"checks":                       "Constraints the environment is expected to satisfy, evaluated with 'envsnap check'.",
+	"checks.versions":              "A mapping of versioned data points (e.g. python.core.version) to the version constraints they must satisfy (e.g. >=3.8,<3.12).",
+	"checks.exec":                  "A mapping of commands to a regular expression which the command output must match.",
+	"value":                        "The value of the item.",
+	"label":                        "The label to display the item with in rendered output, instead of its value. Requires config version 2.",
+	"description":                  "A description of the item, displayed alongside it in rendered output. Requires config version 2.",
+	"hidden":                       "Collect the item, but leave it out of every output format. It is still available to checks. Requires config version 2.",
+	"redact":                       "Replace the collected value of the item with '[redacted]' in all output. Requires config version 2.",
+	"when":                         "The conditions in which this applies. If the conditions are not met, it is skipped.",
+	"when.os":                      "The operating systems (e.g. linux, darwin, windows) on which this applies.",
+	"when.arch":                    "The architectures (e.g. amd64, arm64) on which this applies.",
+	"when.env":                     "Environment variables which must all be set for this to apply.",
+	"when.bin":                     "Binaries which must all exist on the PATH for this to apply.",
+	"profiles":                     "Named subsets of the config sections to render, selected with 'envsnap render --profile <name>'.",
+	"profiles.*.description":       "A description of the profile.",
+	"profiles.*.extends":           "The name of another profile whose sections are also selected by this profile.",
+	"profiles.*.sections":          "The config sections selected by the profile.",
+	"default_profile":              "The profile to render when no profile is specified.",
+	"outputs":                      "The formats to write snapshots in when rendering without the '--output' flag, along with the files to write them to. Takes precedence over 'template', and replaces the outputs of extended configs. Ignored in remote configs.",
+	"outputs.format":               "The output format.",
+	"outputs.file":                 "The file to write the output to, relative to the working directory. If not set, the output is written to stdout.",
+	"checks.environment":           "A list of environment variable names which must be set.",
+}
+
+// jsonSchema is a JSON Schema document, or a subschema within one.
+type jsonSchema map[string]interface{}
+
+// schemaProvider is implemented by config types which may be given in more
+// than one form in the config, and so can not be described based on their Go
+// type alone.
+type schemaProvider interface {
+	jsonSchema(path string) jsonSchema
+}
+
+// ConfigSchema generates a JSON Schema for the envsnap configuration from
+// the configuration structs and the supported values of their options.
+//
+// The schema describes the latest version of the configuration.
+func ConfigSchema() ([]byte, error) {
+	var versions []int
+	for v := range configTypes {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+
+	schema := schemaFor(configTypes[versions[len(versions)-1]], "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = schemaURL
+	schema["title"] = "envsnap configuration"
+	schema["required"] = []string{"version"}
+	schema["properties"].(map[string]jsonSchema)["version"]["enum"] = versions
+
+	// Use an encoder rather than json.MarshalIndent so that characters such
+	// as '<' and '>' in descriptions are not escaped.
+	buffer := bytes.Buffer{}
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// schemaFor generates the subschema for the given type at the given config path.
+func schemaFor(t reflect.Type, path string) jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if sp, ok := reflect.New(t).Elem().Interface().(schemaProvider); ok {
+		schema := sp.jsonSchema(path)
+		if desc := describe(path); desc != "" {
+			schema["description"] = desc
+		}
+		return schema
+	}
+
+	schema := jsonSchema{}
+	if desc := describe(path); desc != "" {
+		schema["description"] = desc
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]jsonSchema{}
+		for name, field := range yamlFields(t) {
+			props[name] = schemaFor(field.Type, joinPath(path, name))
+		}
+		schema["type"] = "object"
+		schema["properties"] = props
+		schema["additionalProperties"] = false
+
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaFor(t.Elem(), joinPath(path, "*"))
+
+	case reflect.Slice:
+		items := schemaFor(t.Elem(), path)
+		delete(items, "description")
+		schema["type"] = "array"
+		schema["items"] = items
+		schema["uniqueItems"] = true
+
+	case reflect.String:
+		schema["type"] = "string"
+		if opts, ok := configOptions[path]; ok {
+			schema["enum"] = opts
+		}
+
+	case reflect.Int:
+		schema["type"] = "integer"
+
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	}
+	return schema
+}
+
+// describe gets the description of the config option at the given path. If
+// there is no description for the full path, the description of the most
+// specific generic option which matches the end of the path is used, e.g.
+// "when.os" for "exec.run.when.os".
+func describe(path string) string {
+	for path != "" {
+		if desc, ok := configDescriptions[path]; ok {
+			return desc
+		}
+		i := strings.IndexByte(path, '.')
+		if i == -1 {
+			break
+		}
+		path = path[i+1:]
+	}
+	return ""
+}
+
+// jsonSchema describes an item in either its string or object form.
+func (Item) jsonSchema(path string) jsonSchema {
+	str := jsonSchema{"type": "string"}
+	if opts, ok := configOptions[path]; ok {
+		str["enum"] = opts
+	}
+
+	obj := schemaFor(reflect.TypeOf(itemObject{}), path)
+	delete(obj, "description")
+	obj["required"] = []string{"value"}
+	obj["properties"].(map[string]jsonSchema)["value"] = jsonSchema{
+		"description": describe("value"),
+		"type":        "string",
+	}
+	if opts, ok := configOptions[path]; ok {
+		obj["properties"].(map[string]jsonSchema)["value"]["enum"] = opts
+	}
+
+	return jsonSchema{"anyOf": []jsonSchema{str, obj}}
+}
+
+// jsonSchema describes a string list in either its string or list form.
+func (stringList) jsonSchema(path string) jsonSchema {
+	return jsonSchema{
+		"anyOf": []jsonSchema{
+			{"type": "string"},
+			{"type": "array", "items": jsonSchema{"type": "string"}},
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSchema(t *testing.T) {
+	data, err := ConfigSchema()
+	assert.NoError(t, err)
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+
+	assert.Equal(t, schemaURL, schema["$id"])
+	assert.Equal(t, []interface{}{"version"}, schema["required"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	props := schema["properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, props["version"].(map[string]interface{})["enum"])
+
+	system := props["system"].(map[string]interface{})["properties"].(map[string]interface{})
+	core := system["core"].(map[string]interface{})
+	assert.Equal(t, "array", core["type"])
+	assert.Equal(t, true, core["uniqueItems"])
+
+	// Items may either be a string or an object.
+	anyOf := core["items"].(map[string]interface{})["anyOf"].([]interface{})
+	assert.Len(t, anyOf, 2)
+
+	str := anyOf[0].(map[string]interface{})
+	assert.Equal(t, "string", str["type"])
+	var opts []string
+	for _, o := range str["enum"].([]interface{}) {
+		opts = append(opts, o.(string))
+	}
+	assert.Equal(t, systemCoreOptions, opts)
+
+	obj := anyOf[1].(map[string]interface{})
+	assert.Equal(t, "object", obj["type"])
+	assert.Equal(t, []interface{}{"value"}, obj["required"])
+	objProps := obj["properties"].(map[string]interface{})
+	assert.Contains(t, objProps, "value")
+	assert.Contains(t, objProps, "when")
+	assert.Len(t, objProps["value"].(map[string]interface{})["enum"], len(systemCoreOptions))
+}
+
+// Every option in the config should have a description in the schema.
+func TestConfigSchema_Descriptions(t *testing.T) {
+	for _, typ := range configTypes {
+		var walk func(s jsonSchema, path string)
+		walk = func(s jsonSchema, path string) {
+			if props, ok := s["properties"].(map[string]jsonSchema); ok {
+				for name, prop := range props {
+					p := joinPath(path, name)
+					assert.NotEmpty(t, describe(p), "missing description for config option: %s", p)
+					walk(prop, p)
+				}
+			}
+			if items, ok := s["items"].(jsonSchema); ok {
+				walk(items, path)
+			}
+			if anyOf, ok := s["anyOf"].([]jsonSchema); ok {
+				for _, sub := range anyOf {
+					walk(sub, path)
+				}
+			}
+		}
+		walk(schemaFor(typ, ""), "")
+	}
+}
+
+// The published schema should be kept in sync with the config definition.
+// If this test fails, regenerate the schema with `make schema`.
+func TestConfigSchema_Published(t *testing.T) {
+	published, err := ioutil.ReadFile("../envsnap.schema.json")
+	assert.NoError(t, err)
+
+	data, err := ConfigSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(published), "envsnap.schema.json is out of date, run `make schema`")
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, configDescriptions["system.core"], describe("system.core"))
+	assert.Equal(t, configDescriptions["when.os"], describe("exec.run.when.os"))
+	assert.Equal(t, configDescriptions["when"], describe("system.when"))
+	assert.Equal(t, "", describe("foo.bar"))
+}
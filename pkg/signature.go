@@ -0,0 +1,330 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The signature algorithm used for config signatures. This is the ed25519
+// algorithm used by minisign for (legacy) non-prehashed signatures, so
+// signatures can be created and verified with minisign as well.
+const sigAlgorithm = "Ed"
+
+// The extension of detached signature files, which are kept next to the
+// config that they sign.
+const sigExt = ".sig"
+
+// The environment variable which may be used to override the directory that
+// trusted public keys are loaded from.
+const keysDirEnv = "ENVSNAP_KEYS_DIR"
+
+// The prefix of the untrusted comment line in key and signature files.
+const untrustedComment = "untrusted comment: "
+
+// The prefix of the trusted comment line in signature files.
+const trustedComment = "trusted comment: "
+
+// PublicKey is a minisign-compatible ed25519 public key, used to verify config
+// signatures.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// SecretKey is an ed25519 secret key, used to sign configs.
+//
+// Unlike minisign secret keys, the key is stored unencrypted in the same
+// format as public keys.
+type SecretKey struct {
+	ID  [8]byte
+	Key ed25519.PrivateKey
+}
+
+// Signature is a minisign-compatible detached signature.
+type Signature struct {
+	KeyID [8]byte
+	Sig   []byte
+
+	// The trusted comment is signed, along with the signature, by the
+	// global signature.
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+// GenerateKey generates a new secret key for signing configs.
+func GenerateKey() (*SecretKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key := &SecretKey{Key: priv}
+	if _, err := rand.Read(key.ID[:]); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// keyID gets the string form of a key ID, as it is displayed by minisign.
+func keyID(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// String gets the ID of the key.
+func (k *PublicKey) String() string {
+	return keyID(k.ID)
+}
+
+// Encode the public key into the minisign public key file format.
+func (k *PublicKey) Encode() []byte {
+	return encodeKeyFile("minisign public key "+k.String(), k.ID, k.Key)
+}
+
+// Verify checks that the signature is a valid signature of the data by this
+// key.
+func (k *PublicKey) Verify(data []byte, sig *Signature) error {
+	if sig.KeyID != k.ID {
+		return fmt.Errorf("%v: signed by key %s, not %s", ErrInvalidSignature, keyID(sig.KeyID), k)
+	}
+	if !ed25519.Verify(k.Key, data, sig.Sig) {
+		return ErrInvalidSignature
+	}
+	global := append(append([]byte{}, sig.Sig...), sig.TrustedComment...)
+	if !ed25519.Verify(k.Key, global, sig.GlobalSig) {
+		return fmt.Errorf("%v: trusted comment does not match", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// Public gets the public key for the secret key.
+func (k *SecretKey) Public() *PublicKey {
+	return &PublicKey{
+		ID:  k.ID,
+		Key: k.Key.Public().(ed25519.PublicKey),
+	}
+}
+
+// Encode the secret key into its file format.
+func (k *SecretKey) Encode() []byte {
+	return encodeKeyFile("envsnap secret key "+keyID(k.ID), k.ID, k.Key)
+}
+
+// Sign the data, returning the signature in the minisign signature file
+// format. The trusted comment is signed along with the data.
+func (k *SecretKey) Sign(data []byte, comment string) []byte {
+	sig := ed25519.Sign(k.Key, data)
+	global := ed25519.Sign(k.Key, append(append([]byte{}, sig...), comment...))
+
+	buf := bytes.Buffer{}
+	buf.WriteString(untrustedComment + "signature from envsnap secret key\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(append(append([]byte(sigAlgorithm), k.ID[:]...), sig...)) + "\n")
+	buf.WriteString(trustedComment + comment + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(global) + "\n")
+	return buf.Bytes()
+}
+
+// encodeKeyFile encodes a key into the minisign key file format.
+func encodeKeyFile(comment string, id [8]byte, key []byte) []byte {
+	data := append(append([]byte(sigAlgorithm), id[:]...), key...)
+	return []byte(untrustedComment + comment + "\n" + base64.StdEncoding.EncodeToString(data) + "\n")
+}
+
+// decodeKeyFile decodes a key from the minisign key file format, checking
+// that the key has the expected size.
+func decodeKeyFile(data []byte, size int) ([8]byte, []byte, error) {
+	var id [8]byte
+
+	lines := fileLines(data)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedComment) {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return id, nil, fmt.Errorf("%v: no key found", ErrInvalidKey)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return id, nil, fmt.Errorf("%v: %v", ErrInvalidKey, err)
+	}
+	if len(raw) != 2+8+size {
+		return id, nil, fmt.Errorf("%v: unexpected key length", ErrInvalidKey)
+	}
+	if string(raw[:2]) != sigAlgorithm {
+		return id, nil, fmt.Errorf("%v: unsupported algorithm: %q", ErrInvalidKey, raw[:2])
+	}
+	copy(id[:], raw[2:10])
+	return id, raw[10:], nil
+}
+
+// ParsePublicKey parses a public key in the minisign public key file format.
+// The untrusted comment line may be omitted, so the key may also be given as
+// just its base64 encoded form.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	id, key, err := decodeKeyFile(data, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{ID: id, Key: ed25519.PublicKey(key)}, nil
+}
+
+// ParseSecretKey parses a secret key, as written by SecretKey.Encode.
+func ParseSecretKey(data []byte) (*SecretKey, error) {
+	id, key, err := decodeKeyFile(data, ed25519.PrivateKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretKey{ID: id, Key: ed25519.PrivateKey(key)}, nil
+}
+
+// ParseSignature parses a signature in the minisign signature file format.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := fileLines(data)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedComment) || !strings.HasPrefix(lines[2], trustedComment) {
+		return nil, fmt.Errorf("%v: malformed signature file", ErrInvalidSignature)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidSignature, err)
+	}
+	if len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, fmt.Errorf("%v: unexpected signature length", ErrInvalidSignature)
+	}
+	if string(raw[:2]) != sigAlgorithm {
+		return nil, fmt.Errorf("%v: unsupported algorithm: %q (sign with 'envsnap sign' or 'minisign -S -l')", ErrInvalidSignature, raw[:2])
+	}
+
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidSignature, err)
+	}
+	if len(global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%v: unexpected signature length", ErrInvalidSignature)
+	}
+
+	sig := &Signature{
+		Sig:            raw[10:],
+		TrustedComment: strings.TrimPrefix(lines[2], trustedComment),
+		GlobalSig:      global,
+	}
+	copy(sig.KeyID[:], raw[2:10])
+	return sig, nil
+}
+
+// fileLines splits a key or signature file into its non-empty lines.
+func fileLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// LoadTrustedKeys loads the public keys (*.pub files) which are trusted to
+// sign remote configs from the given directory. If no directory is given,
+// the ENVSNAP_KEYS_DIR environment variable, or the "envsnap/keys" directory
+// in the user config directory, is used.
+//
+// If the directory does not exist, no keys are trusted.
+func LoadTrustedKeys(dir string) ([]*PublicKey, error) {
+	if dir == "" {
+		dir = os.Getenv(keysDirEnv)
+	}
+	if dir == "" {
+		cfgDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cfgDir, "envsnap", "keys")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pub"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*PublicKey
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// verifySignature verifies the signature of the data against the trusted
+// keys, returning the key which signed it.
+func verifySignature(data, sigData []byte, keys []*PublicKey) (*PublicKey, error) {
+	sig, err := ParseSignature(sigData)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.ID == sig.KeyID {
+			if err := key.Verify(data, sig); err != nil {
+				return nil, err
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: %s", ErrUntrustedKey, keyID(sig.KeyID))
+}
+
+// verifyRemoteConfig verifies the detached signature of a remote config,
+// which is fetched from next to the config, at the same commit.
+func verifyRemoteConfig(cfg *RemoteConfig, keys []*PublicKey) (*PublicKey, error) {
+	ref := cfg.Ref.signatureRef()
+	if cfg.Commit != "" {
+		ref.Ref = cfg.Commit
+	}
+
+	sig, err := remoteCache.Fetch(ref)
+	if err == ErrNoConfig {
+		return nil, fmt.Errorf("%v: %s", ErrNoSignature, ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s: %v", ErrNoSignature, ref, err)
+	}
+	return verifySignature(cfg.Data, sig.Data, keys)
+}
+
+// signatureRef gets the reference to the detached signature of the config.
+// For HTTP(S) configs, the extension is added to the path of the URL, so that
+// the query and fragment are kept as they are.
+func (r RemoteRef) signatureRef() RemoteRef {
+	sig := r
+	switch r.Kind {
+	case remoteHTTP:
+		u, err := url.Parse(r.Repo)
+		if err != nil {
+			sig.Repo += sigExt
+			break
+		}
+		u.Path += sigExt
+		if u.RawPath != "" {
+			u.RawPath += sigExt
+		}
+		sig.Repo = u.String()
+	case remoteFile:
+		sig.Repo += sigExt
+	default:
+		sig.Path += sigExt
+	}
+	return sig
+}
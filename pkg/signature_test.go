@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.Len(t, key.Key, 64)
+	assert.Len(t, key.Public().Key, 32)
+	assert.Equal(t, key.ID, key.Public().ID)
+}
+
+func TestKey_EncodeParse(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	secret, err := ParseSecretKey(key.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, key, secret)
+
+	pub := key.Public().Encode()
+	assert.True(t, strings.HasPrefix(string(pub), "untrusted comment: minisign public key "+key.Public().String()+"\n"))
+
+	public, err := ParsePublicKey(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Public(), public)
+
+	// The key may be given without the comment line.
+	public, err = ParsePublicKey([]byte(strings.SplitN(string(pub), "\n", 2)[1]))
+	assert.NoError(t, err)
+	assert.Equal(t, key.Public(), public)
+}
+
+func TestParsePublicKey_Error(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "", "invalid key: no key found"},
+		{"not base64", "untrusted comment: key\n!!!", "invalid key: illegal base64 data at input byte 0"},
+		{"bad length", "untrusted comment: key\nRWQAAAAA", "invalid key: unexpected key length"},
+		{"bad algorithm", "untrusted comment: key\nWFgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", `invalid key: unsupported algorithm: "XX"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePublicKey([]byte(tt.data))
+			assert.Nil(t, key)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestSecretKey_Sign(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	data := []byte(testRemoteConfig)
+	sig, err := ParseSignature(key.Sign(data, "file:.envsnap"))
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, sig.KeyID)
+	assert.Equal(t, "file:.envsnap", sig.TrustedComment)
+
+	assert.NoError(t, key.Public().Verify(data, sig))
+}
+
+func TestPublicKey_Verify_Error(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	other, err := GenerateKey()
+	assert.NoError(t, err)
+
+	data := []byte(testRemoteConfig)
+	sig, err := ParseSignature(key.Sign(data, "file:.envsnap"))
+	assert.NoError(t, err)
+
+	// Modified data
+	assert.Equal(t, ErrInvalidSignature, key.Public().Verify([]byte("version: 2\n"), sig))
+
+	// Different key
+	assert.EqualError(t, other.Public().Verify(data, sig),
+		"invalid config signature: signed by key "+key.Public().String()+", not "+other.Public().String())
+
+	// Modified trusted comment
+	sig.TrustedComment = "file:other"
+	assert.EqualError(t, key.Public().Verify(data, sig), "invalid config signature: trusted comment does not match")
+}
+
+func TestParseSignature_Error(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	lines := strings.Split(string(key.Sign([]byte(testRemoteConfig), "comment")), "\n")
+
+	var tests = []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "", "invalid config signature: malformed signature file"},
+		{"no trusted comment", strings.Join([]string{lines[0], lines[1], lines[3]}, "\n"), "invalid config signature: malformed signature file"},
+		{"bad signature", strings.Join([]string{lines[0], "RWQA", lines[2], lines[3]}, "\n"), "invalid config signature: unexpected signature length"},
+		{"bad global signature", strings.Join([]string{lines[0], lines[1], lines[2], "AAAA"}, "\n"), "invalid config signature: unexpected signature length"},
+		{"prehashed", strings.Join([]string{lines[0], "RUQ" + lines[1][3:], lines[2], lines[3]}, "\n"), `invalid config signature: unsupported algorithm: "ED" (sign with 'envsnap sign' or 'minisign -S -l')`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseSignature([]byte(tt.data))
+			assert.Nil(t, sig)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test.pub"), key.Public().Encode(), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0644))
+
+	keys, err := LoadTrustedKeys(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []*PublicKey{key.Public()}, keys)
+
+	// The keys directory may be set in the environment.
+	os.Setenv(keysDirEnv, dir)
+	defer os.Unsetenv(keysDirEnv)
+
+	keys, err = LoadTrustedKeys("")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestLoadTrustedKeys_NotExists(t *testing.T) {
+	keys, err := LoadTrustedKeys("this-dir-does-not-exist")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLoadTrustedKeys_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bad.pub"), []byte("not a key"), 0644))
+
+	keys, err := LoadTrustedKeys(dir)
+	assert.Nil(t, keys)
+	assert.Error(t, err)
+}
+
+func TestRemoteRef_SignatureRef(t *testing.T) {
+	var tests = []struct {
+		ref      string
+		expected string
+	}{
+		{"github.com/foo/bar@v1", "github.com/foo/bar//.envsnap.sig@v1"},
+		{"gitlab.com/foo/bar//cfg/base.yml", "gitlab.com/foo/bar//cfg/base.yml.sig"},
+		{"https://example.com/.envsnap", "https://example.com/.envsnap.sig"},
+		{"https://example.com/.envsnap?token=x", "https://example.com/.envsnap.sig?token=x"},
+		{"https://example.com/cfg%2F.envsnap?a=b#c", "https://example.com/cfg%2F.envsnap.sig?a=b#c"},
+		{"file:///tmp/.envsnap", "file:///tmp/.envsnap.sig"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseRemoteRef(tt.ref)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ref.signatureRef().String())
+		})
+	}
+}
+
+func TestLoadConfig_Signed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keysDir := filepath.Join(dir, "keys")
+	assert.NoError(t, os.Mkdir(keysDir, 0755))
+	os.Setenv(keysDirEnv, keysDir)
+	defer os.Unsetenv(keysDirEnv)
+
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(keysDir, "test.pub"), key.Public().Encode(), 0644))
+
+	path := filepath.Join(dir, ".envsnap")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testRemoteConfig), 0644))
+
+	// Unsigned
+	cfg, err := LoadConfig("file://" + path)
+	assert.Nil(t, cfg)
+	assert.EqualError(t, err, "file://"+path+": config signature not found: file://"+path+".sig")
+
+	// Signed by a trusted key
+	assert.NoError(t, ioutil.WriteFile(path+sigExt, key.Sign([]byte(testRemoteConfig), "test"), 0644))
+	cfg, err = LoadConfig("file://" + path)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Public().String(), cfg.Sources()[0].SignedBy)
+
+	// Modified after signing
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testRemoteConfig+"  - PATH\n"), 0644))
+	cfg, err = LoadConfig("file://" + path)
+	assert.Nil(t, cfg)
+	assert.EqualError(t, err, "file://"+path+": invalid config signature")
+
+	// Signed by an untrusted key
+	other, err := GenerateKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path+sigExt, other.Sign([]byte(testRemoteConfig+"  - PATH\n"), "test"), 0644))
+	cfg, err = LoadConfig("file://" + path)
+	assert.Nil(t, cfg)
+	assert.EqualError(t, err, "file://"+path+": config signed by untrusted key: "+other.Public().String())
+}
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"runtime"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/MakeNowJust/heredoc"
@@ -21,9 +24,15 @@ type SysInfo struct {
 	Processor     string
 }
 
+// systemCoreOptions are the supported values for the "system.core" option.
+var systemCoreOptions = []string{
+	"os", "arch", "cpus", "kernel", "kernel_version", "kernel-version", "processor",
+}
+
 // SystemConfig defines the configuration for the "system" source.
 type SystemConfig struct {
-	Core []string `yaml:"core,omitempty"`
+	When *Condition `yaml:"when,omitempty"`
+	Core Items      `yaml:"core,omitempty"`
 }
 
 // Render the SystemConfig into its corresponding SystemResult.
@@ -32,6 +41,10 @@ func (c SystemConfig) Render() (Result, error) {
 	l.Debug("starting render")
 
 	result := NewSystemResult()
+	if !c.When.Applies() {
+		l.Debug("skipping render: condition does not apply")
+		return result, nil
+	}
 
 	info, err := LoadSystemInfo()
 	if err != nil {
@@ -39,20 +52,28 @@ func (c SystemConfig) Render() (Result, error) {
 		cliWarnings.Add("system.core", "error collecting system info")
 	}
 
-	for _, opt := range c.Core {
+	for _, item := range c.Core {
+		if !item.applies(l) {
+			continue
+		}
+		opt := item.Value
+		result.addMeta(strings.Replace(opt, "-", "_", -1), item)
 		switch opt {
 		case "os":
-			result.OS = info.OS
+			result.OS = item.redact(info.OS)
 		case "arch":
-			result.Arch = info.Arch
+			result.Arch = item.redact(info.Arch)
 		case "cpus":
-			result.CPUs = runtime.NumCPU()
+			result.CPUs = Count(runtime.NumCPU())
+			if item.Redact {
+				result.CPUs = redactedCount
+			}
 		case "kernel":
-			result.Kernel = info.Kernel
+			result.Kernel = item.redact(info.Kernel)
 		case "kernel_version", "kernel-version":
-			result.KernelVersion = info.KernelVersion
+			result.KernelVersion = item.redact(info.KernelVersion)
 		case "processor":
-			result.Processor = info.Processor
+			result.Processor = item.redact(info.Processor)
 		default:
 			l.WithField("opt", opt).Debug("unsupported core system option")
 			return result, fmt.Errorf("unsupported core system option: %s", opt)
@@ -69,12 +90,87 @@ type SystemResult struct {
 	// Core
 	OS            string `yaml:"os,omitempty" json:"os,omitempty"`
 	Arch          string `yaml:"arch,omitempty" json:"arch,omitempty"`
-	CPUs          int    `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	CPUs          Count  `yaml:"cpus,omitempty" json:"cpus,omitempty"`
 	Kernel        string `yaml:"kernel,omitempty" json:"kernel,omitempty"`
 	KernelVersion string `yaml:"kernel_version,omitempty" json:"kernel_version,omitempty"`
 	Processor     string `yaml:"processor,omitempty" json:"processor,omitempty"`
 }
 
+// Count is a number in a result, such as the number of CPUs, which may be
+// redacted. A redacted count is rendered and serialized as the redacted
+// placeholder, rather than as a number.
+type Count int
+
+// The value of a Count which is redacted.
+const redactedCount Count = -1
+
+// String gets the count as it is rendered.
+func (c Count) String() string {
+	if c == redactedCount {
+		return redactedValue
+	}
+	return strconv.Itoa(int(c))
+}
+
+// value gets the count as it is serialized: the number, or the redacted
+// placeholder.
+func (c Count) value() interface{} {
+	if c == redactedCount {
+		return redactedValue
+	}
+	return int(c)
+}
+
+// MarshalJSON marshals the count into its serialized form (see value).
+func (c Count) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.value())
+}
+
+// UnmarshalJSON unmarshals a count from either a number or the redacted
+// placeholder.
+func (c *Count) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return c.parse(s)
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*c = Count(n)
+	return nil
+}
+
+// MarshalYAML marshals the count into its serialized form (see value).
+func (c Count) MarshalYAML() (interface{}, error) {
+	return c.value(), nil
+}
+
+// UnmarshalYAML unmarshals a count from either a number or the redacted
+// placeholder.
+func (c *Count) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var n int
+	if err := unmarshal(&n); err == nil {
+		*c = Count(n)
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return c.parse(s)
+}
+
+// parse sets the count from a serialized string, which may only be the
+// redacted placeholder.
+func (c *Count) parse(s string) error {
+	if s != redactedValue {
+		return fmt.Errorf("invalid count: %q", s)
+	}
+	*c = redactedCount
+	return nil
+}
+
 // NewSystemResult creates a new instance of an SystemResult.
 func NewSystemResult() SystemResult {
 	return SystemResult{
@@ -87,6 +183,28 @@ func (r SystemResult) IsEmpty() bool {
 	return r.OS == "" && r.Arch == "" && r.CPUs == 0 && r.KernelVersion == "" && r.Kernel == "" && r.Processor == ""
 }
 
+// withoutHidden gets a copy of the result without the hidden items.
+func (r SystemResult) withoutHidden() SystemResult {
+	for _, key := range r.hidden() {
+		switch key {
+		case "os":
+			r.OS = ""
+		case "arch":
+			r.Arch = ""
+		case "cpus":
+			r.CPUs = 0
+		case "kernel":
+			r.Kernel = ""
+		case "kernel_version":
+			r.KernelVersion = ""
+		case "processor":
+			r.Processor = ""
+		}
+	}
+	r.Meta = r.visibleMeta()
+	return r
+}
+
 // Markdown renders the SystemResult to markdown.
 func (r SystemResult) Markdown() ([]byte, error) {
 	log.WithField("src", "system").Debug("rendering to markdown")
@@ -96,13 +214,13 @@ func (r SystemResult) Markdown() ([]byte, error) {
 	}
 
 	md := heredoc.Doc(`
-		**System**{{ if .OS }}
-		- _os_: {{ .OS }}{{ end }}{{ if .Arch }}
-		- _arch_: {{ .Arch }}{{ end }}{{ if .CPUs }}
-		- _cpus_: {{ .CPUs }}{{ end }}{{ if .Kernel }}
-		- _kernel_: {{ .Kernel }}{{ end }}{{ if .KernelVersion }}
-		- _kernel version_: {{ .KernelVersion }}{{ end }}{{ if .Processor }}
-		- _processor_: {{ .Processor }}{{ end }}
+		**System**{{ if and .OS ($.Shown "os") }}
+		- _{{ $.Label "os" "os" }}_: {{ .OS }}{{ $.Describe "os" }}{{ end }}{{ if and .Arch ($.Shown "arch") }}
+		- _{{ $.Label "arch" "arch" }}_: {{ .Arch }}{{ $.Describe "arch" }}{{ end }}{{ if and .CPUs ($.Shown "cpus") }}
+		- _{{ $.Label "cpus" "cpus" }}_: {{ .CPUs }}{{ $.Describe "cpus" }}{{ end }}{{ if and .Kernel ($.Shown "kernel") }}
+		- _{{ $.Label "kernel" "kernel" }}_: {{ .Kernel }}{{ $.Describe "kernel" }}{{ end }}{{ if and .KernelVersion ($.Shown "kernel_version") }}
+		- _{{ $.Label "kernel_version" "kernel version" }}_: {{ .KernelVersion }}{{ $.Describe "kernel_version" }}{{ end }}{{ if and .Processor ($.Shown "processor") }}
+		- _{{ $.Label "processor" "processor" }}_: {{ .Processor }}{{ $.Describe "processor" }}{{ end }}
 	`)
 	t := template.Must(template.New("system-md").Parse(md))
 
@@ -123,13 +241,13 @@ func (r SystemResult) Plaintext() ([]byte, error) {
 
 	plaintext := heredoc.Doc(`
 		System
-		------{{ if .OS }}
-		os:             {{ .OS }}{{ end }}{{ if .Arch }}
-		arch:           {{ .Arch }}{{ end }}{{ if .CPUs }}
-		cpus:           {{ .CPUs }}{{ end }}{{ if .Kernel }}
-		kernel:         {{ .Kernel }}{{ end }}{{ if .KernelVersion }}
-		kernel version: {{ .KernelVersion }}{{ end }}{{ if .Processor }}
-		processor:      {{ .Processor }}{{ end }}
+		------{{ if and .OS ($.Shown "os") }}
+		{{ printf "%-15s" (print ($.Label "os" "os") ":") }} {{ .OS }}{{ $.Describe "os" }}{{ end }}{{ if and .Arch ($.Shown "arch") }}
+		{{ printf "%-15s" (print ($.Label "arch" "arch") ":") }} {{ .Arch }}{{ $.Describe "arch" }}{{ end }}{{ if and .CPUs ($.Shown "cpus") }}
+		{{ printf "%-15s" (print ($.Label "cpus" "cpus") ":") }} {{ .CPUs }}{{ $.Describe "cpus" }}{{ end }}{{ if and .Kernel ($.Shown "kernel") }}
+		{{ printf "%-15s" (print ($.Label "kernel" "kernel") ":") }} {{ .Kernel }}{{ $.Describe "kernel" }}{{ end }}{{ if and .KernelVersion ($.Shown "kernel_version") }}
+		{{ printf "%-15s" (print ($.Label "kernel_version" "kernel version") ":") }} {{ .KernelVersion }}{{ $.Describe "kernel_version" }}{{ end }}{{ if and .Processor ($.Shown "processor") }}
+		{{ printf "%-15s" (print ($.Label "processor" "processor") ":") }} {{ .Processor }}{{ $.Describe "processor" }}{{ end }}
 	`)
 
 	t := template.Must(template.New("system-txt").Parse(plaintext))
@@ -141,6 +259,67 @@ func (r SystemResult) Plaintext() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// GFM renders the SystemResult to GitHub-flavored markdown.
+func (r SystemResult) GFM() ([]byte, error) {
+	log.WithField("src", "system").Debug("rendering to GitHub-flavored markdown")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	gfm := heredoc.Doc(`
+		<details><summary>System</summary>
+
+		| Key | Value |
+		| :--- | :--- |{{ if and .OS ($.Shown "os") }}
+		| {{ $.Cell ($.Label "os" "os") }} | {{ $.Cell (print .OS ($.Describe "os")) }} |{{ end }}{{ if and .Arch ($.Shown "arch") }}
+		| {{ $.Cell ($.Label "arch" "arch") }} | {{ $.Cell (print .Arch ($.Describe "arch")) }} |{{ end }}{{ if and .CPUs ($.Shown "cpus") }}
+		| {{ $.Cell ($.Label "cpus" "cpus") }} | {{ $.Cell (print .CPUs ($.Describe "cpus")) }} |{{ end }}{{ if and .Kernel ($.Shown "kernel") }}
+		| {{ $.Cell ($.Label "kernel" "kernel") }} | {{ $.Cell (print .Kernel ($.Describe "kernel")) }} |{{ end }}{{ if and .KernelVersion ($.Shown "kernel_version") }}
+		| {{ $.Cell ($.Label "kernel_version" "kernel version") }} | {{ $.Cell (print .KernelVersion ($.Describe "kernel_version")) }} |{{ end }}{{ if and .Processor ($.Shown "processor") }}
+		| {{ $.Cell ($.Label "processor" "processor") }} | {{ $.Cell (print .Processor ($.Describe "processor")) }} |{{ end }}
+		
+		</details>
+	`)
+	t := template.Must(template.New("system-gfm").Parse(gfm))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// HTML renders the SystemResult to an HTML section.
+func (r SystemResult) HTML() ([]byte, error) {
+	log.WithField("src", "system").Debug("rendering to HTML")
+
+	if r.IsEmpty() {
+		return []byte{}, nil
+	}
+
+	html := heredoc.Doc(`
+		<details class="section" open>
+		<summary>System</summary>
+		<table>{{ if and .OS ($.Shown "os") }}
+		<tr><th>{{ $.Label "os" "os" }}</th><td>{{ .OS }}{{ with $.Description "os" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .Arch ($.Shown "arch") }}
+		<tr><th>{{ $.Label "arch" "arch" }}</th><td>{{ .Arch }}{{ with $.Description "arch" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .CPUs ($.Shown "cpus") }}
+		<tr><th>{{ $.Label "cpus" "cpus" }}</th><td>{{ .CPUs }}{{ with $.Description "cpus" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .Kernel ($.Shown "kernel") }}
+		<tr><th>{{ $.Label "kernel" "kernel" }}</th><td>{{ .Kernel }}{{ with $.Description "kernel" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .KernelVersion ($.Shown "kernel_version") }}
+		<tr><th>{{ $.Label "kernel_version" "kernel version" }}</th><td>{{ .KernelVersion }}{{ with $.Description "kernel_version" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}{{ if and .Processor ($.Shown "processor") }}
+		<tr><th>{{ $.Label "processor" "processor" }}</th><td>{{ .Processor }}{{ with $.Description "processor" }} <span class="description">{{ . }}</span>{{ end }}</td></tr>{{ end }}
+		</table>
+		</details>
+	`)
+	t := htmltemplate.Must(htmltemplate.New("system-html").Parse(html))
+
+	buffer := bytes.Buffer{}
+	if err := t.Execute(&buffer, r); err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // YAML renders the SystemResult to YAML.
 func (r SystemResult) YAML() ([]byte, error) {
 	log.WithField("src", "system").Debug("rendering to YAML")
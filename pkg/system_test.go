@@ -1,9 +1,12 @@
 package pkg
 
 import (
+	"encoding/json"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestSystemConfig_Render_NoOpts(t *testing.T) {
@@ -24,7 +27,7 @@ func TestSystemConfig_Render_NoOpts(t *testing.T) {
 
 func TestSystemConfig_Render_BadOpt(t *testing.T) {
 	cfg := SystemConfig{
-		Core: []string{"not-an-option"},
+		Core: []Item{{Value: "not-an-option"}},
 	}
 
 	r, err := cfg.Render()
@@ -34,8 +37,13 @@ func TestSystemConfig_Render_BadOpt(t *testing.T) {
 
 func TestSystemConfig_Render(t *testing.T) {
 	cfg := SystemConfig{
-		Core: []string{
-			"os", "arch", "cpus", "kernel", "kernel_version", "processor",
+		Core: []Item{
+			{Value: "os"},
+			{Value: "arch"},
+			{Value: "cpus"},
+			{Value: "kernel"},
+			{Value: "kernel_version"},
+			{Value: "processor"},
 		},
 	}
 
@@ -54,8 +62,8 @@ func TestSystemConfig_Render(t *testing.T) {
 
 func TestSystemConfig_Render_Alternatives(t *testing.T) {
 	cfg := SystemConfig{
-		Core: []string{
-			"kernel-version",
+		Core: []Item{
+			{Value: "kernel-version"},
 		},
 	}
 
@@ -169,6 +177,68 @@ func TestSystemResult_Plaintext(t *testing.T) {
 	assert.Equal(t, expected, string(data))
 }
 
+func TestSystemResult_Meta(t *testing.T) {
+	r := NewSystemResult()
+	r.OS = "darwin"
+	r.Arch = "x86_64"
+	r.Kernel = "Darwin"
+	r.addMeta("os", Item{Value: "os", Label: "platform", Description: "host os"})
+	r.addMeta("kernel", Item{Value: "kernel", Hidden: true})
+
+	data, err := r.Markdown()
+	assert.NoError(t, err)
+	assert.Equal(t, "**System**\n- _platform_: darwin (host os)\n- _arch_: x86_64\n", string(data))
+
+	data, err = r.Plaintext()
+	assert.NoError(t, err)
+	assert.Equal(t, "System\n------\nplatform:       darwin (host os)\narch:           x86_64\n", string(data))
+}
+
+func TestSystemResult_GFM(t *testing.T) {
+	r := NewSystemResult()
+	r.OS = "darwin"
+	r.CPUs = 12
+	r.Kernel = "Darwin"
+	r.addMeta("kernel", Item{Value: "kernel", Hidden: true})
+	r.addMeta("cpus", Item{Value: "cpus", Label: "CPU count"})
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+
+	expected := "<details><summary>System</summary>\n\n| Key | Value |\n| :--- | :--- |\n| os | darwin |\n| CPU count | 12 |\n\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestSystemResult_GFM_Empty(t *testing.T) {
+	r := NewSystemResult()
+
+	data, err := r.GFM()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestSystemResult_HTML(t *testing.T) {
+	r := NewSystemResult()
+	r.OS = "darwin"
+	r.CPUs = 12
+	r.Kernel = "Darwin"
+	r.addMeta("kernel", Item{Value: "kernel", Hidden: true})
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+
+	expected := "<details class=\"section\" open>\n<summary>System</summary>\n<table>\n<tr><th>os</th><td>darwin</td></tr>\n<tr><th>cpus</th><td>12</td></tr>\n</table>\n</details>\n"
+	assert.Equal(t, expected, string(data))
+}
+
+func TestSystemResult_HTML_Empty(t *testing.T) {
+	r := NewSystemResult()
+
+	data, err := r.HTML()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
 func TestSystemResult_Plaintext_Empty(t *testing.T) {
 	r := NewSystemResult()
 
@@ -224,3 +294,61 @@ func TestSystemResult_YAML_Empty(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, data)
 }
+
+func TestSystemConfig_Render_When(t *testing.T) {
+	cfg := SystemConfig{
+		Core: []Item{
+			{Value: "os", When: &Condition{OS: stringList{runtime.GOOS}}},
+			{Value: "arch", When: &Condition{OS: stringList{"not-an-os"}}},
+		},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+
+	res := r.(SystemResult)
+	assert.Equal(t, runtime.GOOS, res.OS)
+	assert.Empty(t, res.Arch)
+}
+
+func TestSystemConfig_Render_Redact(t *testing.T) {
+	cfg := SystemConfig{
+		Core: []Item{
+			{Value: "os", Redact: true},
+			{Value: "cpus", Redact: true},
+		},
+	}
+
+	r, err := cfg.Render()
+	assert.NoError(t, err)
+
+	res := r.(SystemResult)
+	assert.Equal(t, redactedValue, res.OS)
+	assert.Equal(t, redactedCount, res.CPUs)
+	assert.Equal(t, redactedValue, res.CPUs.String())
+}
+
+func TestCount_Serialize(t *testing.T) {
+	for _, count := range []Count{4, redactedCount} {
+		data, err := json.Marshal(SystemResult{CPUs: count})
+		assert.NoError(t, err)
+
+		var fromJSON SystemResult
+		assert.NoError(t, json.Unmarshal(data, &fromJSON))
+		assert.Equal(t, count, fromJSON.CPUs)
+
+		data, err = yaml.Marshal(SystemResult{CPUs: count})
+		assert.NoError(t, err)
+
+		var fromYAML SystemResult
+		assert.NoError(t, yaml.Unmarshal(data, &fromYAML))
+		assert.Equal(t, count, fromYAML.CPUs)
+	}
+
+	data, err := json.Marshal(SystemResult{CPUs: redactedCount})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"cpus":"[redacted]"}`, string(data))
+
+	var res SystemResult
+	assert.Error(t, json.Unmarshal([]byte(`{"cpus":"many"}`), &res))
+}
@@ -48,6 +48,7 @@ var CommandVersionTemplate = heredoc.Doc(`
 
 // EnvsnapInitTemplate is the template for the boilerplate envsnap config.
 var EnvsnapInitTemplate = heredoc.Doc(`
+	# yaml-language-server: $schema={{ .Schema }}
 	# envsnap configuration (yaml format)
 	# use 'envsnap show' to generate an environment snapshot
 	# for more details, see: https://www.github.com/edaniszewski/envsnap
@@ -75,3 +76,58 @@ var EnvsnapInitTemplate = heredoc.Doc(`
 	  - version
 	{{ end -}}
 `)
+
+// HTMLDocumentTemplate is the template for the HTML output. It wraps the HTML
+// sections rendered by each result in a self-contained document, with all
+// styles and scripts inlined so the document can be viewed without any
+// external assets.
+var HTMLDocumentTemplate = heredoc.Doc(`
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>Environment</title>
+	<style>
+	body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; max-width: 960px; margin: 2em auto; padding: 0 1em; line-height: 1.5; }
+	h1 { font-size: 1.5em; border-bottom: 1px solid #e1e4e8; padding-bottom: 0.3em; }
+	details.section { border: 1px solid #e1e4e8; border-radius: 6px; margin: 1em 0; padding: 0 1em; }
+	details.section > summary { cursor: pointer; font-weight: 600; padding: 0.5em 0; }
+	table { border-collapse: collapse; margin-bottom: 1em; width: 100%; }
+	th, td { border: 1px solid #e1e4e8; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+	th { background: #f6f8fa; white-space: nowrap; width: 1%; }
+	code, pre { font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 0.9em; }
+	pre { background: #f6f8fa; border-radius: 6px; margin: 0; overflow: auto; padding: 0.8em; white-space: pre-wrap; }
+	.code { position: relative; margin-bottom: 1em; }
+	.copy { position: absolute; top: 0.4em; right: 0.4em; cursor: pointer; font-size: 0.8em; }
+	.description { color: #6a737d; }
+	</style>
+	</head>
+	<body>
+	<h1>Environment</h1>
+	{{ range .Sections }}{{ . }}{{ end -}}
+	<script>
+	function copyText(text) {
+	  if (navigator.clipboard) {
+	    return navigator.clipboard.writeText(text);
+	  }
+	  var area = document.createElement("textarea");
+	  area.value = text;
+	  document.body.appendChild(area);
+	  area.select();
+	  document.execCommand("copy");
+	  document.body.removeChild(area);
+	  return Promise.resolve();
+	}
+	document.querySelectorAll("button.copy").forEach(function (button) {
+	  button.addEventListener("click", function () {
+	    copyText(button.parentNode.querySelector("pre").textContent).then(function () {
+	      button.textContent = "Copied";
+	      setTimeout(function () { button.textContent = "Copy"; }, 1500);
+	    });
+	  });
+	});
+	</script>
+	</body>
+	</html>
+`)
@@ -0,0 +1,215 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The key in the config which sets the template to render snapshots with.
+const templateKey = "template"
+
+// templateFuncs are the functions available to user templates, in addition
+// to the built-in template functions. They are modeled on the functions of
+// the sprig library, which many Go template users will be familiar with.
+//
+// There is deliberately no function to read environment variables, as the
+// template may come from an extended or remote config, and the rendered
+// output is often shared publicly.
+var templateFuncs = template.FuncMap{
+	// Strings
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      strings.Title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+	"quote":      func(s interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(s)) },
+	"squote":     func(s interface{}) string { return "'" + fmt.Sprint(s) + "'" },
+	"indent":     indent,
+	"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"lines":      func(s string) []string { return strings.Split(strings.TrimRight(s, "\n"), "\n") },
+	"join":       join,
+
+	// Defaults
+	"default":  func(def, value interface{}) interface{} { return coalesce(value, def) },
+	"empty":    isEmpty,
+	"coalesce": coalesce,
+	"ternary": func(yes, no interface{}, cond bool) interface{} {
+		if cond {
+			return yes
+		}
+		return no
+	},
+
+	// Data
+	"keys":         keys,
+	"toJson":       toJSON,
+	"toPrettyJson": toPrettyJSON,
+	"toYaml":       toYAML,
+
+	// Time
+	"now": time.Now,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+
+	// Sections in the built-in formats
+	"markdown":  formatSection(Result.Markdown),
+	"plaintext": formatSection(Result.Plaintext),
+	"gfm":       formatSection(Result.GFM),
+}
+
+// RenderTemplate renders the snapshot data with the given user template.
+func RenderTemplate(text string, data interface{}) (string, error) {
+	t, err := template.New("user").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer strings.Builder
+	if err := t.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// loadTemplate loads a user template, either from a local file or from a
+// remote source.
+func loadTemplate(path string) (string, error) {
+	if isRemoteConfig(path) {
+		remote, err := fetchConfig(path)
+		if err != nil {
+			return "", err
+		}
+		return string(remote.Data), nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatSection creates a template function which renders a section of the
+// snapshot in one of the built-in formats. Sections which are not in the
+// snapshot render as an empty string.
+func formatSection(format func(Result) ([]byte, error)) func(interface{}) (string, error) {
+	return func(section interface{}) (string, error) {
+		if isNil(section) {
+			return "", nil
+		}
+		res, ok := section.(Result)
+		if !ok {
+			return "", fmt.Errorf("expected a snapshot section, got %T", section)
+		}
+		data, err := format(res)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// indent indents each line of the string by the given number of spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+// join joins the elements of a list, formatted as strings, with the separator.
+func join(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// keys gets the sorted keys of a map, formatted as strings.
+func keys(m interface{}) ([]string, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil, fmt.Errorf("keys: expected a map, got %T", m)
+	}
+	var k []string
+	for _, key := range v.MapKeys() {
+		k = append(k, fmt.Sprint(key.Interface()))
+	}
+	sort.Strings(k)
+	return k, nil
+}
+
+// isNil checks whether the value is nil, including nil pointers, maps and
+// slices held in an interface.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isEmpty checks whether the value is nil or the zero value of its type, or
+// an empty map, slice or string.
+func isEmpty(value interface{}) bool {
+	if isNil(value) {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(value, reflect.Zero(v.Type()).Interface())
+}
+
+// coalesce gets the first of the values which is not empty.
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// toJSON encodes the value as JSON.
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// toPrettyJSON encodes the value as indented JSON.
+func toPrettyJSON(value interface{}) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	return string(data), err
+}
+
+// toYAML encodes the value as YAML.
+func toYAML(value interface{}) (string, error) {
+	data, err := yaml.Marshal(value)
+	return strings.TrimSuffix(string(data), "\n"), err
+}
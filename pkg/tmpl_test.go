@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+	sys.Arch = "amd64"
+
+	env := NewEnvResult()
+	env.Env["B"] = "2"
+	env.Env["A"] = "1"
+
+	res := NewV1EnvsnapResult()
+	res.System = sys
+	res.Environment = env
+	res.Python = NewPythonResult()
+
+	out, err := res.Template(`{{ .System.OS | upper }}/{{ .System.Arch }}{{ range keys .Environment.Env }} {{ . }}{{ end }}{{ if not .Python }} no python{{ end }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "LINUX/amd64 A B no python", out)
+}
+
+func TestRenderTemplate_Sections(t *testing.T) {
+	sys := NewSystemResult()
+	sys.OS = "linux"
+
+	res := NewV1EnvsnapResult()
+	res.System = sys
+
+	out, err := res.Template(`{{ markdown .System }}{{ plaintext .System }}{{ gfm .Golang }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "**System**\n- _os_: linux\nSystem\n------\nos:             linux\n", out)
+}
+
+func TestRenderTemplate_Meta(t *testing.T) {
+	res := NewV1EnvsnapResult()
+	out, err := res.Template(`{{ with .Meta }}{{ .Hostname }}{{ else }}no meta{{ end }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "no meta", out)
+
+	res.Meta = &SnapshotMeta{CapturedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Hostname: "host"}
+	out, err = res.Template(`{{ .Meta.Hostname }} {{ .Meta.Captured }}{{ plaintext .Meta }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "host 2020-01-02T03:04:05ZSnapshot\n--------\ncaptured at:   2020-01-02T03:04:05Z\nhostname:      host\n", out)
+}
+
+func TestRenderTemplate_Err(t *testing.T) {
+	_, err := RenderTemplate(`{{ .Foo `, nil)
+	assert.Error(t, err)
+
+	_, err = RenderTemplate(`{{ markdown "foo" }}`, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected a snapshot section, got string")
+
+	_, err = RenderTemplate(`{{ keys "foo" }}`, nil)
+	assert.Error(t, err)
+
+	// Templates can not read environment variables which are not in the
+	// snapshot.
+	_, err = RenderTemplate(`{{ env "HOME" }}`, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `function "env" not defined`)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	var tests = []struct {
+		tmpl     string
+		expected string
+	}{
+		{`{{ "Foo" | upper }}`, "FOO"},
+		{`{{ "Foo" | lower }}`, "foo"},
+		{`{{ "foo bar" | title }}`, "Foo Bar"},
+		{`{{ " foo " | trim }}`, "foo"},
+		{`{{ "go1.13" | trimPrefix "go" }}`, "1.13"},
+		{`{{ "a.txt" | trimSuffix ".txt" }}`, "a"},
+		{`{{ "a-b-c" | replace "-" "." }}`, "a.b.c"},
+		{`{{ contains "b" "abc" }}`, "true"},
+		{`{{ hasPrefix "a" "abc" }}`, "true"},
+		{`{{ hasSuffix "a" "abc" }}`, "false"},
+		{`{{ "ab" | repeat 2 }}`, "abab"},
+		{`{{ "a b" | quote }}`, `"a b"`},
+		{`{{ 1 | squote }}`, "'1'"},
+		{`{{ "a\nb" | indent 2 }}`, "  a\n  b"},
+		{`{{ "a" | nindent 2 }}`, "\n  a"},
+		{`{{ splitList "," "a,b" | join "+" }}`, "a+b"},
+		{`{{ range lines "a\nb\n" }}[{{ . }}]{{ end }}`, "[a][b]"},
+		{`{{ "" | default "none" }}`, "none"},
+		{`{{ "x" | default "none" }}`, "x"},
+		{`{{ empty "" }} {{ empty 0 }} {{ empty "a" }}`, "true true false"},
+		{`{{ coalesce "" "b" "c" }}`, "b"},
+		{`{{ true | ternary "yes" "no" }}`, "yes"},
+		{`{{ toJson (splitList "," "a,b") }}`, `["a","b"]`},
+		{`{{ toPrettyJson (splitList "," "a") }}`, "[\n  \"a\"\n]"},
+		{`{{ toYaml (splitList "," "a,b") }}`, "- a\n- b"},
+		{`{{ now | date "2006" | len }}`, "4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tmpl, func(t *testing.T) {
+			out, err := RenderTemplate(test.tmpl, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestTemplateFuncs_Date(t *testing.T) {
+	out, err := RenderTemplate(`{{ date "2006-01-02" . }}`, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-01-02", out)
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.tmpl")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{{ .Version }}"), 0644))
+
+	tmpl, err := loadTemplate(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "{{ .Version }}", tmpl)
+
+	_, err = loadTemplate(filepath.Join(dir, "missing.tmpl"))
+	assert.Error(t, err)
+}
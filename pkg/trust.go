@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The environment variable which may be used to override the path of the
+// trust store.
+const trustStoreEnv = "ENVSNAP_TRUST_STORE"
+
+// TrustStore holds the remote configs which the user has trusted to run
+// exec commands, keyed by the digest of their contents and of the commands
+// they run (see ConfigSource.trustKey). A remote config whose contents or
+// commands change must be trusted again.
+type TrustStore struct {
+	// Path is the path of the trust store file. If empty, the
+	// ENVSNAP_TRUST_STORE environment variable, or the user config
+	// directory, is used.
+	Path string `json:"-"`
+
+	Configs map[string]TrustedConfig `json:"configs"`
+}
+
+// TrustedConfig is a remote config in the trust store.
+type TrustedConfig struct {
+	Ref     string    `json:"ref"`
+	Trusted time.Time `json:"trusted"`
+}
+
+// LoadTrustStore loads the trust store at the given path, as described by
+// TrustStore.Path. If the trust store does not exist yet, it is empty.
+func LoadTrustStore(path string) (*TrustStore, error) {
+	store := &TrustStore{
+		Path:    path,
+		Configs: map[string]TrustedConfig{},
+	}
+	path, err := store.path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if store.Configs == nil {
+		store.Configs = map[string]TrustedConfig{}
+	}
+	return store, nil
+}
+
+// path gets the path of the trust store file.
+func (s *TrustStore) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+	if env := os.Getenv(trustStoreEnv); env != "" {
+		return env, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "envsnap", "trusted.json"), nil
+}
+
+// Untrusted gets the sources which list exec commands and are not trusted.
+func (s *TrustStore) Untrusted(sources []ConfigSource) []ConfigSource {
+	var untrusted []ConfigSource
+	for _, src := range sources {
+		if len(src.exec) == 0 {
+			continue
+		}
+		if _, ok := s.Configs[src.trustKey()]; !ok {
+			untrusted = append(untrusted, src)
+		}
+	}
+	return untrusted
+}
+
+// Trust adds the sources to the trust store and saves it.
+func (s *TrustStore) Trust(sources []ConfigSource) error {
+	for _, src := range sources {
+		s.Configs[src.trustKey()] = TrustedConfig{
+			Ref:     src.Ref,
+			Trusted: time.Now().UTC(),
+		}
+	}
+
+	path, err := s.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// trustKey gets the key which the source is trusted by. The commands run by
+// a config are interpolated (see interpolateConfig), so they may differ with
+// where it is used, e.g. with the repository root; the key is therefore the
+// digest of the config's contents along with the commands it runs.
+func (s ConfigSource) trustKey() string {
+	h := sha256.New()
+	io.WriteString(h, s.Digest)
+	for _, cmd := range s.exec {
+		io.WriteString(h, "\x00"+cmd)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+// confirmTrust shows the exec commands listed by the untrusted sources and
+// asks the user whether to run them.
+func confirmTrust(in io.Reader, out io.Writer, sources []ConfigSource) (bool, error) {
+	fmt.Fprintln(out, "The following remote configs will run commands on this machine:")
+	for _, src := range sources {
+		fmt.Fprintf(out, "\n  %s (%s)\n", src.Ref, src.Digest)
+		for _, cmd := range src.exec {
+			fmt.Fprintf(out, "    $ %s\n", cmd)
+		}
+	}
+	fmt.Fprint(out, "\nTrust these configs and run the commands? [y/N] ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// execCommands gets the commands which the config will run, either listed
+// in the exec section or referenced by exec checks.
+func (c V1EnvsnapConfig) execCommands() []string {
+	cmds := c.Exec.Run.Values()
+	var checks []string
+	for cmd := range c.Checks.Exec {
+		if !c.Exec.Run.Contains(cmd) {
+			checks = append(checks, cmd)
+		}
+	}
+	sort.Strings(checks)
+	return append(cmds, checks...)
+}
+
+// Sources gets the remote configs which the config was loaded from.
+func (c V1EnvsnapConfig) Sources() []ConfigSource {
+	return c.sources
+}
+
+// ExecSources gets the remote configs which list any of the commands that
+// the config will run: those in its exec section, along with those of its
+// exec checks if checks is set. Commands which are not run, e.g. as their
+// section is left out by a profile, do not need to be trusted.
+//
+// The sources are returned with all of the commands they list, as they are
+// trusted for all of them (see ConfigSource.trustKey).
+func (c V1EnvsnapConfig) ExecSources(checks bool) []ConfigSource {
+	run := stringList(c.Exec.Run.Values())
+	if checks {
+		run = c.execCommands()
+	}
+
+	var sources []ConfigSource
+	for _, src := range c.sources {
+		for _, cmd := range src.exec {
+			if run.Contains(cmd) {
+				sources = append(sources, src)
+				break
+			}
+		}
+	}
+	return sources
+}
+
+// WithoutExec gets a copy of the config which does not run any commands,
+// i.e. with the exec section and exec checks removed.
+func (c V1EnvsnapConfig) WithoutExec() EnvsnapConfig {
+	cfg := c
+	cfg.Exec = ExecConfig{}
+	cfg.Checks.Exec = nil
+	return &cfg
+}
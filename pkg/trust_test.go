@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTrustStore_NotExists(t *testing.T) {
+	store, err := LoadTrustStore("this-file-does-not-exist.json")
+	assert.NoError(t, err)
+	assert.Empty(t, store.Configs)
+}
+
+func TestLoadTrustStore_Invalid(t *testing.T) {
+	file, err := ioutil.TempFile("", "envsnap-trust")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("not json")
+	assert.NoError(t, err)
+
+	store, err := LoadTrustStore(file.Name())
+	assert.Nil(t, store)
+	assert.Error(t, err)
+}
+
+func TestTrustStore_Trust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-trust")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "envsnap", "trusted.json")
+	sources := []ConfigSource{
+		{Ref: "github.com/foo/bar", Digest: "sha256:abc", exec: []string{"make --version"}},
+		{Ref: "github.com/foo/baz", Digest: "sha256:def", exec: []string{"go version"}},
+	}
+
+	store, err := LoadTrustStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, sources, store.Untrusted(sources))
+
+	assert.NoError(t, store.Trust(sources[:1]))
+
+	// Trusted configs are remembered across loads.
+	store, err = LoadTrustStore(path)
+	assert.NoError(t, err)
+	assert.Len(t, store.Configs, 1)
+	assert.Equal(t, "github.com/foo/bar", store.Configs[sources[0].trustKey()].Ref)
+	assert.Equal(t, sources[1:], store.Untrusted(sources))
+
+	// A config must be trusted again if its commands change.
+	changed := sources[0]
+	changed.exec = []string{"/other/repo/.venv/bin/python --version"}
+	assert.Equal(t, []ConfigSource{changed}, store.Untrusted([]ConfigSource{changed}))
+}
+
+func TestTrustStore_Untrusted_NoExec(t *testing.T) {
+	store, err := LoadTrustStore("this-file-does-not-exist.json")
+	assert.NoError(t, err)
+
+	// Configs which do not run any commands do not need to be trusted.
+	assert.Empty(t, store.Untrusted([]ConfigSource{{Ref: "github.com/foo/bar", Digest: "sha256:abc"}}))
+}
+
+func TestTrustStore_Path(t *testing.T) {
+	os.Setenv(trustStoreEnv, "/tmp/trusted.json")
+	defer os.Unsetenv(trustStoreEnv)
+
+	store := &TrustStore{}
+	path, err := store.path()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/trusted.json", path)
+
+	store = &TrustStore{Path: "/tmp/other.json"}
+	path, err = store.path()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/other.json", path)
+}
+
+func TestConfirmTrust(t *testing.T) {
+	var tests = []struct {
+		answer string
+		ok     bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	sources := []ConfigSource{
+		{Ref: "github.com/foo/bar", Digest: "sha256:abc", exec: []string{"make --version", "go version"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			out := bytes.Buffer{}
+			ok, err := confirmTrust(strings.NewReader(tt.answer), &out, sources)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t,
+				"The following remote configs will run commands on this machine:\n\n"+
+					"  github.com/foo/bar (sha256:abc)\n"+
+					"    $ make --version\n"+
+					"    $ go version\n\n"+
+					"Trust these configs and run the commands? [y/N] ",
+				out.String(),
+			)
+		})
+	}
+}
+
+func TestV1EnvsnapConfig_ExecCommands(t *testing.T) {
+	cfg := V1EnvsnapConfig{
+		Exec: ExecConfig{
+			Run: Items{{Value: "make --version"}},
+		},
+		Checks: CheckConfig{
+			Exec: map[string]string{
+				"make --version": "GNU",
+				"go version":     "go1",
+			},
+		},
+	}
+	assert.Equal(t, []string{"make --version", "go version"}, cfg.execCommands())
+}
+
+func TestV1EnvsnapConfig_WithoutExec(t *testing.T) {
+	cfg := V1EnvsnapConfig{
+		Exec: ExecConfig{
+			Run: Items{{Value: "make --version"}},
+		},
+		Checks: CheckConfig{
+			Exec:        map[string]string{"go version": "go1"},
+			Environment: []string{"HOME"},
+		},
+		sources: []ConfigSource{{Ref: "github.com/foo/bar"}},
+	}
+
+	c := cfg.WithoutExec().(*V1EnvsnapConfig)
+	assert.Empty(t, c.Exec.Run)
+	assert.Empty(t, c.Checks.Exec)
+	assert.Equal(t, []string{"HOME"}, c.Checks.Environment)
+	assert.Equal(t, cfg.sources, c.Sources())
+
+	// The original config is not modified.
+	assert.Len(t, cfg.Exec.Run, 1)
+	assert.Len(t, cfg.Checks.Exec, 1)
+}
+
+func TestV1EnvsnapConfig_ExecSources(t *testing.T) {
+	run := ConfigSource{Ref: "github.com/foo/run", Digest: "sha256:abc", exec: []string{"make --version"}}
+	check := ConfigSource{Ref: "github.com/foo/check", Digest: "sha256:def", exec: []string{"go version"}}
+	none := ConfigSource{Ref: "github.com/foo/none", Digest: "sha256:123"}
+
+	cfg := V1EnvsnapConfig{
+		Exec: ExecConfig{
+			Run: Items{{Value: "make --version"}},
+		},
+		Checks: CheckConfig{
+			Exec: map[string]string{"go version": "go1"},
+		},
+		Profiles: map[string]Profile{
+			"system": {Sections: []string{"system"}},
+		},
+		sources: []ConfigSource{run, check, none},
+	}
+
+	// Commands of exec checks are only run by checks.
+	assert.Equal(t, []ConfigSource{run}, cfg.ExecSources(false))
+	assert.Equal(t, []ConfigSource{run, check}, cfg.ExecSources(true))
+
+	// A profile without the exec section runs no commands, so no config
+	// needs to be trusted to render it.
+	c, err := cfg.WithProfile("system")
+	assert.NoError(t, err)
+	assert.Empty(t, c.ExecSources(false))
+
+	store, err := LoadTrustStore("this-file-does-not-exist.json")
+	assert.NoError(t, err)
+	assert.Empty(t, store.Untrusted(c.ExecSources(false)))
+	assert.Equal(t, []ConfigSource{run}, store.Untrusted(cfg.ExecSources(false)))
+}
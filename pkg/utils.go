@@ -2,7 +2,11 @@ package pkg
 
 import (
 	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -41,3 +45,87 @@ func runCommand(name string, args ...string) (bytes.Buffer, bytes.Buffer, error)
 
 	return stdout, stderr, err
 }
+
+// isTerminal is a helper function which checks whether the given file
+// is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// fileExists is a helper function which checks whether a file exists at the
+// given path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeFileAtomic is a helper function which writes data to a file so that
+// the file is either replaced entirely or left as it was: the data is written
+// to a temporary file in the same directory, which is then renamed over the
+// file. The parent directories of the file are created if they do not exist.
+//
+// As with ioutil.WriteFile, a new file is created with the given permissions
+// less the umask, and an existing file keeps its permissions. If the file is
+// a symlink, the file it links to is replaced, rather than the link itself.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := createTemp(dir, filepath.Base(path), perm)
+	if err != nil {
+		return err
+	}
+	// Once renamed, the temporary file no longer exists, so removing it has
+	// no effect.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info != nil {
+		if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// createTemp creates a new temporary file in the directory, named after the
+// file it is written for. Unlike ioutil.TempFile, the file is created with
+// the given permissions less the umask.
+func createTemp(dir, name string, perm os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		var suffix [4]byte
+		if _, err := rand.Read(suffix[:]); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf(".%s.tmp%x", name, suffix))
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) && i < 100 {
+			continue
+		}
+		return f, err
+	}
+}
@@ -1,6 +1,10 @@
 package pkg
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -63,3 +67,108 @@ func TestToSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestFileExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.True(t, fileExists(dir))
+	assert.False(t, fileExists(filepath.Join(dir, "missing")))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The parent directories are created.
+	path := filepath.Join(dir, "a", "b", "out.txt")
+	err = writeFileAtomic(path, []byte("first"), 0666)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	// The file is created with the permissions less the umask, the same as
+	// with ioutil.WriteFile.
+	ref := filepath.Join(dir, "ref.txt")
+	assert.NoError(t, ioutil.WriteFile(ref, []byte("ref"), 0666))
+	refInfo, err := os.Stat(ref)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, refInfo.Mode().Perm(), info.Mode().Perm())
+
+	// An existing file is replaced, and no temporary files are left behind.
+	err = writeFileAtomic(path, []byte("second"), 0644)
+	assert.NoError(t, err)
+
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestWriteFileAtomic_ExistingMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("first"), 0600))
+	assert.NoError(t, os.Chmod(path, 0600))
+
+	// An existing file keeps its permissions.
+	err = writeFileAtomic(path, []byte("second"), 0644)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestWriteFileAtomic_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require extra privileges on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	assert.NoError(t, ioutil.WriteFile(target, []byte("first"), 0644))
+	assert.NoError(t, os.Symlink(target, link))
+
+	// The file the link points to is written, and the link is kept.
+	err = writeFileAtomic(link, []byte("second"), 0644)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Lstat(link)
+	assert.NoError(t, err)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0)
+}
+
+func TestWriteFileAtomic_Err(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The file can not be written if its parent is a file.
+	parent := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(parent, []byte("data"), 0644))
+
+	err = writeFileAtomic(filepath.Join(parent, "out.txt"), []byte("data"), 0644)
+	assert.Error(t, err)
+}
@@ -0,0 +1,529 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// configTypes maps each supported configuration version to the struct which
+// that version of the configuration is decoded into.
+var configTypes = map[int]reflect.Type{
+	configV1: reflect.TypeOf(V1EnvsnapConfig{}),
+	configV2: reflect.TypeOf(V1EnvsnapConfig{}),
+}
+
+// configOptions maps config paths to the values which are supported for
+// that option. Values of the option which are not listed are reported when
+// validating the config.
+var configOptions = map[string][]string{
+	"system.core": systemCoreOptions,
+	"go.core":     golangCoreOptions,
+	"python.core": pythonCoreOptions,
+
+	"profiles.*.sections": configSections,
+	"outputs.format":      outputFormats,
+}
+
+// configValueValidators maps config paths to functions which validate the
+// scalar values found at that path. Map values are addressed by a "*"
+// segment, e.g. "checks.versions.*".
+var configValueValidators = map[string]func(string) error{
+	"checks.versions.*": func(v string) error {
+		_, err := ParseVersionConstraints(v)
+		return err
+	},
+	"checks.exec.*": func(v string) error {
+		if _, err := regexp.Compile(v); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+		return nil
+	},
+}
+
+// configKeyValidators maps config paths of mappings to functions which
+// validate the keys of that mapping.
+var configKeyValidators = map[string]func(string) error{
+	"checks.versions": func(k string) error {
+		_, err := versionOf(&V1EnvsnapResult{}, k)
+		return err
+	},
+}
+
+// nodeValidator is implemented by config types which may be given in more than
+// one form in the config, and so can not be validated based on their Go type
+// alone.
+type nodeValidator interface {
+	validateNode(v *validator, node *yamlv3.Node, path string)
+}
+
+// ValidationError describes a single problem found in a configuration file.
+type ValidationError struct {
+	Line   int
+	Column int
+
+	// Path is the dotted path to the config option the problem was found in.
+	Path    string
+	Message string
+}
+
+// Error returns the validation error as a string.
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidationErrors is a collection of problems found in a configuration file,
+// ordered by their position in the file.
+type ValidationErrors []ValidationError
+
+// Print out the validation errors.
+//
+// Each error is printed on its own line, prefixed with the name of the
+// validated file and the position of the problem within the file.
+func (e ValidationErrors) Print(writer io.Writer, file string) {
+	red := color.New(color.FgRed)
+	for _, err := range e {
+		red.Fprintf(writer, "%s:%s\n", file, err.Error())
+	}
+}
+
+// ValidateConfig validates the raw configuration data without rendering it.
+//
+// Rather than stopping at the first problem, every problem found is reported,
+// including unknown keys, unsupported option values, duplicate keys and list
+// entries, and values of the wrong type. An error is returned only if the
+// data could not be parsed as YAML at all.
+func ValidateConfig(data []byte) (ValidationErrors, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	v := &validator{}
+	root := resolveNode(&doc)
+	if root == nil {
+		v.add(&yamlv3.Node{Line: 1, Column: 1}, "", ErrNoConfigVersion.Error())
+		return v.errs, nil
+	}
+	if root.Kind != yamlv3.MappingNode {
+		v.add(root, "", fmt.Sprintf("expected a mapping, got %s", kindName(root)))
+		return v.errs, nil
+	}
+
+	// Determine which version of the configuration to validate against.
+	var version *yamlv3.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			version = root.Content[i+1]
+		}
+	}
+	if version == nil {
+		v.add(root, "", ErrNoConfigVersion.Error())
+		return v.errs, nil
+	}
+	var ver int
+	if err := version.Decode(&ver); err != nil {
+		v.add(version, "version", fmt.Sprintf("expected an integer, got %s", kindName(version)))
+		return v.errs, nil
+	}
+	t, ok := configTypes[ver]
+	if !ok {
+		v.add(version, "version", fmt.Sprintf("%s: %d", ErrInvalidConfigVersion.Error(), ver))
+		return v.errs, nil
+	}
+
+	v.version = ver
+	v.validate(root, t, "")
+
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+	return v.errs, nil
+}
+
+// validator walks a YAML node tree alongside the Go type that it is decoded
+// into, collecting any problems it finds along the way.
+type validator struct {
+	errs ValidationErrors
+
+	// version is the version of the config being validated.
+	version int
+}
+
+// add a new validation error for the given node.
+func (v *validator) add(node *yamlv3.Node, path, msg string) {
+	v.errs = append(v.errs, ValidationError{
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: msg,
+	})
+}
+
+// validate the node against the type it is decoded into.
+func (v *validator) validate(node *yamlv3.Node, t reflect.Type, path string) {
+	node = resolveNode(node)
+	if node == nil || isNull(node) {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if nv, ok := reflect.New(t).Elem().Interface().(nodeValidator); ok {
+		nv.validateNode(v, node, path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		v.validateStruct(node, t, path)
+
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			v.add(node, path, fmt.Sprintf("expected a mapping, got %s", kindName(node)))
+			return
+		}
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, val := node.Content[i], node.Content[i+1]
+			if seen[key.Value] {
+				v.add(key, path, fmt.Sprintf("duplicate key '%s'", key.Value))
+			}
+			seen[key.Value] = true
+			if fn, ok := configKeyValidators[path]; ok {
+				if err := fn(key.Value); err != nil {
+					v.add(key, path, err.Error())
+				}
+			}
+			v.validate(val, t.Elem(), joinPath(path, "*"))
+		}
+
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			v.add(node, path, fmt.Sprintf("expected a list, got %s", kindName(node)))
+			return
+		}
+		seen := map[string]bool{}
+		for _, item := range node.Content {
+			item = resolveNode(item)
+			if key := itemKey(item); key != "" {
+				if seen[key] {
+					v.add(item, path, fmt.Sprintf("duplicate entry '%s'", key))
+				}
+				seen[key] = true
+			}
+			v.validate(item, t.Elem(), path)
+		}
+
+	case reflect.String:
+		if node.Kind != yamlv3.ScalarNode {
+			v.add(node, path, fmt.Sprintf("expected a string, got %s", kindName(node)))
+			return
+		}
+		v.validateValue(node, path)
+
+	case reflect.Int:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			v.add(node, path, fmt.Sprintf("expected an integer, got %s", kindName(node)))
+		}
+
+	case reflect.Bool:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!bool" {
+			v.add(node, path, fmt.Sprintf("expected a boolean, got %s", kindName(node)))
+		}
+	}
+}
+
+// validateStruct validates a mapping node against the fields of a struct,
+// reporting any keys which do not correspond to a field.
+func (v *validator) validateStruct(node *yamlv3.Node, t reflect.Type, path string) {
+	if node.Kind != yamlv3.MappingNode {
+		v.add(node, path, fmt.Sprintf("expected a mapping, got %s", kindName(node)))
+		return
+	}
+
+	fields := yamlFields(t)
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			v.add(key, path, fmt.Sprintf("duplicate key '%s'", key.Value))
+		}
+		seen[key.Value] = true
+
+		field, ok := fields[key.Value]
+		if !ok {
+			msg := fmt.Sprintf("unknown key '%s'", key.Value)
+			if suggestion := closestMatch(key.Value, fieldNames(fields)); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			v.add(key, path, msg)
+			continue
+		}
+		v.validate(val, field.Type, joinPath(path, key.Value))
+	}
+}
+
+// validateValue validates the value of a scalar node against the supported
+// option values and value validators registered for its path.
+func (v *validator) validateValue(node *yamlv3.Node, path string) {
+	// Values which use interpolation can only be validated once they have
+	// been expanded, when the config is loaded.
+	if hasTemplate(node.Value) {
+		return
+	}
+	if opts, ok := configOptions[path]; ok {
+		supported := false
+		for _, opt := range opts {
+			if node.Value == opt {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			msg := fmt.Sprintf("unsupported option '%s'", node.Value)
+			if suggestion := closestMatch(node.Value, opts); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			v.add(node, path, msg)
+		}
+	}
+	if fn, ok := configValueValidators[path]; ok {
+		if err := fn(node.Value); err != nil {
+			v.add(node, path, err.Error())
+		}
+	}
+}
+
+// validateNode validates an item in either its string or object form.
+func (Item) validateNode(v *validator, node *yamlv3.Node, path string) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		v.validateValue(node, path)
+	case yamlv3.MappingNode:
+		v.validateStruct(node, reflect.TypeOf(itemObject{}), path)
+		if v.version == configV1 {
+			for _, key := range itemMetaFields {
+				if meta := mappingKey(node, key); meta != nil {
+					v.add(meta, path+"."+key, "item metadata requires config version 2")
+				}
+			}
+		}
+		value := mappingValue(node, "value")
+		if value == nil {
+			v.add(node, path, "missing required key 'value'")
+			return
+		}
+		if value.Kind == yamlv3.ScalarNode {
+			v.validateValue(value, path)
+		}
+	default:
+		v.add(node, path, fmt.Sprintf("expected a string or a mapping, got %s", kindName(node)))
+	}
+}
+
+// validateNode validates a string list in either its string or list form.
+func (stringList) validateNode(v *validator, node *yamlv3.Node, path string) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		return
+	case yamlv3.SequenceNode:
+		v.validate(node, reflect.TypeOf([]string{}), path)
+	default:
+		v.add(node, path, fmt.Sprintf("expected a string or a list, got %s", kindName(node)))
+	}
+}
+
+// itemKey gets the value which identifies a list entry for the purpose of
+// detecting duplicates: the value of a scalar, or the "value" key of an item
+// in object form.
+func itemKey(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		return node.Value
+	case yamlv3.MappingNode:
+		if value := mappingValue(node, "value"); value != nil && value.Kind == yamlv3.ScalarNode {
+			return value.Value
+		}
+	}
+	return ""
+}
+
+// mappingValue gets the value node for the given key in a mapping node, or
+// nil if the key does not exist.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveNode(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// mappingKey gets the key node for the given key in a mapping node, or nil
+// if the mapping does not have the key.
+func mappingKey(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// yamlFields gets the fields of a struct keyed by their YAML key. Fields of
+// inlined structs are included.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("yaml")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		inline := false
+		for _, flag := range parts[1:] {
+			if flag == "inline" {
+				inline = true
+			}
+		}
+		if inline && f.Type.Kind() == reflect.Struct {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// fieldNames gets the sorted names of the given fields.
+func fieldNames(fields map[string]reflect.StructField) []string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closestMatch finds the candidate which is closest to the given value, for
+// suggesting corrections to typos. If no candidate is reasonably close, an
+// empty string is returned.
+func closestMatch(value string, candidates []string) string {
+	best, bestDist := "", len(value)/2+1
+	for _, c := range candidates {
+		if d := levenshtein(value, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein computes the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// minInt returns the smallest of the given ints.
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, i := range rest {
+		if i < m {
+			m = i
+		}
+	}
+	return m
+}
+
+// resolveNode unwraps document and alias nodes to get the node holding
+// the actual content.
+func resolveNode(node *yamlv3.Node) *yamlv3.Node {
+	for node != nil {
+		switch node.Kind {
+		case 0:
+			// An empty document has no content at all.
+			return nil
+		case yamlv3.DocumentNode:
+			if len(node.Content) == 0 {
+				return nil
+			}
+			node = node.Content[0]
+		case yamlv3.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return node
+}
+
+// isNull checks whether the node is a YAML null value.
+func isNull(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.Tag == "!!null"
+}
+
+// kindName gets a human-readable description of the kind of value a node holds.
+func kindName(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "a mapping"
+	case yamlv3.SequenceNode:
+		return "a list"
+	case yamlv3.ScalarNode:
+		switch node.Tag {
+		case "!!int":
+			return "an integer"
+		case "!!float":
+			return "a float"
+		case "!!bool":
+			return "a boolean"
+		case "!!null":
+			return "null"
+		}
+		return "a string"
+	}
+	return "an unknown value"
+}
+
+// joinPath joins config path segments with a dot.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
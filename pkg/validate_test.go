@@ -0,0 +1,281 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 1
+		system:
+		  core:
+		  - os
+		  - arch
+		environment:
+		  variables:
+		  - PATH
+		python:
+		  core:
+		  - version
+		  dependencies:
+		    packages:
+		    - requests
+		checks:
+		  versions:
+		    python.core.version: ">=3.8,<3.12"
+		  exec:
+		    go version: "go1"
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+}
+
+func TestValidateConfig_Errors(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 1
+		enviroment:
+		  variables:
+		  - PATH
+		system:
+		  core:
+		  - os
+		  - os
+		  - kernal
+		go:
+		  core: version
+		python:
+		  denpendencies: {}
+		  core:
+		  - version
+		exec:
+		  run:
+		  - ls
+		exec:
+		  run:
+		    foo: bar
+		checks:
+		  versions:
+		    go.core.version: "abc"
+		    foo.bar: ">=1"
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+
+	expected := []string{
+		"2:1: unknown key 'enviroment' (did you mean 'environment'?)",
+		"8:5: system.core: duplicate entry 'os'",
+		"9:5: system.core: unsupported option 'kernal' (did you mean 'kernel'?)",
+		"11:9: go.core: expected a list, got a string",
+		"13:3: python: unknown key 'denpendencies' (did you mean 'dependencies'?)",
+		"19:1: duplicate key 'exec'",
+		"21:5: exec.run: expected a list, got a mapping",
+		"24:22: checks.versions.*: invalid version constraint: 'abc': invalid version: abc",
+		"25:5: checks.versions: unsupported version check: foo.bar",
+	}
+	var actual []string
+	for _, e := range errs {
+		actual = append(actual, e.Error())
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestValidateConfig_NoVersion(t *testing.T) {
+	errs, err := ValidateConfig([]byte("system:\n  core: [os]\n"))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "1:1: no version specified in config", errs[0].Error())
+}
+
+func TestValidateConfig_Empty(t *testing.T) {
+	errs, err := ValidateConfig([]byte(""))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "1:1: no version specified in config", errs[0].Error())
+}
+
+func TestValidateConfig_InvalidVersion(t *testing.T) {
+	errs, err := ValidateConfig([]byte("version: 99\n"))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "1:10: version: invalid config version specified: 99", errs[0].Error())
+
+	errs, err = ValidateConfig([]byte("version: one\n"))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "1:10: version: expected an integer, got a string", errs[0].Error())
+}
+
+func TestValidateConfig_NotMapping(t *testing.T) {
+	errs, err := ValidateConfig([]byte("- version\n"))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "1:1: expected a mapping, got a list", errs[0].Error())
+}
+
+func TestValidateConfig_ParseErr(t *testing.T) {
+	errs, err := ValidateConfig([]byte("version: 1\n  system: [\n"))
+	assert.Error(t, err)
+	assert.Nil(t, errs)
+}
+
+func TestValidationErrors_Print(t *testing.T) {
+	errs := ValidationErrors{
+		{Line: 2, Column: 1, Message: "unknown key 'foo'"},
+		{Line: 4, Column: 3, Path: "system.core", Message: "unsupported option 'bar'"},
+	}
+
+	out := bytes.Buffer{}
+	errs.Print(&out, ".envsnap")
+
+	assert.Contains(t, out.String(), ".envsnap:2:1: unknown key 'foo'\n")
+	assert.Contains(t, out.String(), ".envsnap:4:3: system.core: unsupported option 'bar'\n")
+}
+
+func TestLevenshtein(t *testing.T) {
+	var tests = []struct {
+		a    string
+		b    string
+		dist int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"kernal", "kernel", 1},
+		{"enviroment", "environment", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.dist, levenshtein(tt.a, tt.b))
+		})
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	assert.Equal(t, "kernel", closestMatch("kernal", systemCoreOptions))
+	assert.Equal(t, "", closestMatch("foobarbaz", systemCoreOptions))
+}
+
+func TestValidateConfig_Items(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 1
+		system:
+		  when:
+		    os: [linux, darwin]
+		  core:
+		  - os
+		  - value: arch
+		    when:
+		      arch: amd64
+		  - value: os
+		  - value: kernal
+		  - when:
+		      os: linux
+		  - [foo]
+		exec:
+		  run:
+		  - value: sw_vers
+		    when:
+		      os: darwin
+		      foo: bar
+		      bin: {brew: true}
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+
+	expected := []string{
+		"10:5: system.core: duplicate entry 'os'",
+		"11:12: system.core: unsupported option 'kernal' (did you mean 'kernel'?)",
+		"12:5: system.core: missing required key 'value'",
+		"14:5: system.core: expected a string or a mapping, got a list",
+		"20:7: exec.run.when: unknown key 'foo'",
+		"21:12: exec.run.when.bin: expected a string or a list, got a mapping",
+	}
+	var actual []string
+	for _, e := range errs {
+		actual = append(actual, e.Error())
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestValidateConfig_ItemMeta(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 1
+		exec:
+		  run:
+		  - value: docker --version
+		    label: Docker engine
+		    hidden: true
+		  - value: uname
+		    redact: yes please
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+
+	expected := []string{
+		"5:5: exec.run.label: item metadata requires config version 2",
+		"6:5: exec.run.hidden: item metadata requires config version 2",
+		"8:5: exec.run.redact: item metadata requires config version 2",
+		"8:13: exec.run.redact: expected a boolean, got a string",
+	}
+	var actual []string
+	for _, e := range errs {
+		actual = append(actual, e.Error())
+	}
+	assert.Equal(t, expected, actual)
+
+	// The same config is valid in version 2, other than the bad value.
+	errs, err = ValidateConfig([]byte(strings.Replace(data, "version: 1", "version: 2", 1)))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+func TestValidateConfig_Vars(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 1
+		vars:
+		  core: version
+		  pyver: ">=3.8"
+		python:
+		  core:
+		  - ${core}
+		checks:
+		  versions:
+		    python.core.version: "{{ .Vars.pyver }}"
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+	assert.Len(t, errs, 0)
+}
+
+func TestValidateConfig_Outputs(t *testing.T) {
+	data := heredoc.Doc(`
+		version: 2
+		outputs:
+		- format: json
+		  file: snapshot.json
+		- format: jsn
+		- file: snapshot.md
+		  formt: md
+	`)
+
+	errs, err := ValidateConfig([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, ValidationErrors{
+		{Line: 5, Column: 11, Path: "outputs.format", Message: "unsupported option 'jsn' (did you mean 'json'?)"},
+		{Line: 7, Column: 3, Path: "outputs", Message: "unknown key 'formt' (did you mean 'format'?)"},
+	}, errs)
+}
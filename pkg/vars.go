@@ -0,0 +1,267 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// The key in the config which defines the variables for interpolation.
+const varsKey = "vars"
+
+// shellVarPattern matches "${name}" references to variables. A reference
+// may be escaped as "$${name}" to produce a literal "${name}".
+var shellVarPattern = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// templateData is the data which is available to templates in config values.
+type templateData struct {
+	// Vars are the variables defined in the "vars" section of the config,
+	// along with those inherited from the configs it extends.
+	Vars map[string]string
+
+	// Env holds the environment variables. It is empty for remote configs.
+	Env map[string]string
+
+	OS   string
+	Arch string
+
+	// Root is the root directory of the repository containing the config.
+	Root string
+
+	// remote is set for remote configs, which may not read the environment.
+	remote bool
+}
+
+// newTemplateData creates the data for templates in config values. The root
+// is the root of the repository that the config belongs to. Remote configs
+// do not have access to the environment, so that they can not read secrets
+// from it.
+func newTemplateData(root string, remote bool) *templateData {
+	env := map[string]string{}
+	if !remote {
+		for _, e := range os.Environ() {
+			parts := strings.SplitN(e, "=", 2)
+			if len(parts) == 2 {
+				env[parts[0]] = parts[1]
+			}
+		}
+	}
+	return &templateData{
+		Vars:   map[string]string{},
+		Env:    env,
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+		Root:   root,
+		remote: remote,
+	}
+}
+
+// hasTemplate checks whether a config value uses interpolation.
+func hasTemplate(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "${")
+}
+
+// interpolate expands the variable references and Go templates in a value.
+//
+// A "${name}" reference expands to the variable with that name, or to the
+// environment variable with that name if there is no such variable. Go
+// templates are executed with the templateData.
+func (d *templateData) interpolate(s string) (string, error) {
+	if !hasTemplate(s) {
+		return s, nil
+	}
+
+	var err error
+	s = shellVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := ref[2 : len(ref)-1]
+		if v, ok := d.Vars[name]; ok {
+			return v
+		}
+		if v, ok := d.Env[name]; ok {
+			return v
+		}
+		if err == nil {
+			err = fmt.Errorf("undefined variable: %s", name)
+		}
+		return ref
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, err := template.New("value").Option("missingkey=error").Funcs(template.FuncMap{
+		"env": d.env,
+	}).Parse(s)
+	if err != nil {
+		return "", err
+	}
+	out := bytes.Buffer{}
+	if err := tmpl.Execute(&out, d); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// env gets the value of an environment variable, for the "env" template
+// function. Remote configs may not read the environment.
+func (d *templateData) env(name string) (string, error) {
+	if d.remote {
+		return "", fmt.Errorf("environment variables are not available to remote configs")
+	}
+	return os.Getenv(name), nil
+}
+
+// interpolateConfig expands the variables and templates in all keys and values
+// of the raw config at the given path, and gets the variables it defines.
+//
+// Interpolation is opt-in: only configs which define variables (even if no
+// "vars" are given, e.g. "vars: {}"), or which extend configs that do, are
+// interpolated. Other configs are used as they are, so that values such as
+// "docker info --format {{.ServerVersion}}" are not taken for templates.
+//
+// The inherited variables are those defined by the extended configs, which
+// the config's own variables override. The variables defined in the "vars"
+// section are expanded first; they may use environment variables, the
+// built-in values and the inherited variables, but not each other. Errors are reported with the
+// path of the offending key.
+func interpolateConfig(path string, raw map[interface{}]interface{}, inherited map[string]string) (map[interface{}]interface{}, map[string]string, error) {
+	vars, hasVars := raw[varsKey].(map[interface{}]interface{})
+	_, declared := raw[varsKey]
+	if !declared && len(inherited) == 0 {
+		return raw, nil, nil
+	}
+
+	root, err := configRoot(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := newTemplateData(root, isRemoteConfig(path))
+	for k, v := range inherited {
+		data.Vars[k] = v
+	}
+
+	own := map[string]string{}
+	if hasVars {
+		for _, k := range sortedKeys(vars) {
+			key := fmt.Sprint(k)
+			s, ok := vars[k].(string)
+			if !ok {
+				own[key] = fmt.Sprint(vars[k])
+				continue
+			}
+			v, err := data.interpolate(s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%v: %s: %v", ErrInterpolation, joinPath(varsKey, key), err)
+			}
+			own[key] = v
+		}
+	}
+	for k, v := range own {
+		data.Vars[k] = v
+	}
+
+	out := make(map[interface{}]interface{}, len(raw))
+	for _, k := range sortedKeys(raw) {
+		if k == varsKey {
+			out[k] = raw[k]
+			continue
+		}
+		v, err := data.interpolateValue(raw[k], fmt.Sprint(k))
+		if err != nil {
+			return nil, nil, err
+		}
+		out[k] = v
+	}
+	return out, data.Vars, nil
+}
+
+// interpolateValue expands the variables and templates in a raw config value
+// at the given path.
+func (d *templateData) interpolateValue(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		s, err := d.interpolate(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s: %v", ErrInterpolation, path, err)
+		}
+		return s, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded, err := d.interpolateValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(v))
+		for _, k := range sortedKeys(v) {
+			key := k
+			if s, ok := k.(string); ok {
+				expanded, err := d.interpolate(s)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %s: %v", ErrInterpolation, joinPath(path, s), err)
+				}
+				key = expanded
+			}
+			expanded, err := d.interpolateValue(v[k], joinPath(path, fmt.Sprint(key)))
+			if err != nil {
+				return nil, err
+			}
+			out[key] = expanded
+		}
+		return out, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// sortedKeys gets the keys of a raw config mapping in sorted order, so that
+// interpolation errors are reported deterministically.
+func sortedKeys(m map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// repoRoot gets the root directory of the repository containing the given
+// directory (the closest directory containing .git). If the directory is not
+// in a repository, the directory itself is returned.
+func repoRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
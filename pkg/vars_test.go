@@ -0,0 +1,352 @@
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateData_Interpolate(t *testing.T) {
+	data := &templateData{
+		Vars: map[string]string{"venv": "/repo/.venv", "pkg": "envsnap"},
+		Env:  map[string]string{"HOME": "/home/test", "pkg": "from-env"},
+		OS:   "linux",
+		Arch: "amd64",
+		Root: "/repo",
+	}
+
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{"make --version", "make --version"},
+		{"${venv}/bin/python --version", "/repo/.venv/bin/python --version"},
+		{"${pkg}", "envsnap"},
+		{"${HOME}/.config", "/home/test/.config"},
+		{"$${venv}", "${venv}"},
+		{"$venv", "$venv"},
+		{"{{ .Vars.venv }}/bin/pip", "/repo/.venv/bin/pip"},
+		{"{{ .Env.HOME }}", "/home/test"},
+		{"{{ .OS }}-{{ .Arch }}", "linux-amd64"},
+		{"{{ .Root }}/go.mod", "/repo/go.mod"},
+		{"{{ if eq .OS \"linux\" }}lsb_release -a{{ else }}sw_vers{{ end }}", "lsb_release -a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := data.interpolate(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestTemplateData_Interpolate_Error(t *testing.T) {
+	data := &templateData{
+		Vars: map[string]string{},
+		Env:  map[string]string{},
+	}
+
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{"${missing}", "undefined variable: missing"},
+		{"{{ .Vars.missing }}", `template: value:1:8: executing "value" at <.Vars.missing>: map has no entry for key "missing"`},
+		{"{{ .Missing }}", `template: value:1:3: executing "value" at <.Missing>: can't evaluate field Missing in type *pkg.templateData`},
+		{"{{ .OS", "template: value:1: unclosed action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := data.interpolate(tt.in)
+			assert.Empty(t, out)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestTemplateData_Interpolate_EnvFunc(t *testing.T) {
+	os.Setenv("ENVSNAP_TEST_VAR", "value")
+	defer os.Unsetenv("ENVSNAP_TEST_VAR")
+
+	data := newTemplateData("/repo", false)
+	out, err := data.interpolate(`{{ env "ENVSNAP_TEST_VAR" }}:{{ env "ENVSNAP_TEST_UNSET" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "value:", out)
+}
+
+func TestTemplateData_Interpolate_Remote(t *testing.T) {
+	os.Setenv("ENVSNAP_TEST_VAR", "value")
+	defer os.Unsetenv("ENVSNAP_TEST_VAR")
+
+	data := newTemplateData("/repo", true)
+	assert.Empty(t, data.Env)
+
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{"${ENVSNAP_TEST_VAR}", "undefined variable: ENVSNAP_TEST_VAR"},
+		{"{{ .Env.ENVSNAP_TEST_VAR }}", `template: value:1:7: executing "value" at <.Env.ENVSNAP_TEST_VAR>: map has no entry for key "ENVSNAP_TEST_VAR"`},
+		{`{{ env "ENVSNAP_TEST_VAR" }}`, `template: value:1:3: executing "value" at <env "ENVSNAP_TEST_VAR">: error calling env: environment variables are not available to remote configs`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := data.interpolate(tt.in)
+			assert.Empty(t, out)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestInterpolateConfig(t *testing.T) {
+	raw := map[interface{}]interface{}{
+		"version": 1,
+		"vars": map[interface{}]interface{}{
+			"venv": "{{ .Root }}/.venv",
+			"pkg":  "envsnap",
+			"num":  3,
+		},
+		"exec": map[interface{}]interface{}{
+			"run": []interface{}{
+				"${venv}/bin/python --version",
+				map[interface{}]interface{}{
+					"value": "${venv}/bin/pip show ${pkg}",
+					"when":  map[interface{}]interface{}{"os": "{{ .OS }}"},
+				},
+			},
+		},
+		"checks": map[interface{}]interface{}{
+			"exec": map[interface{}]interface{}{
+				"${venv}/bin/python --version": "Python 3",
+			},
+			"versions": map[interface{}]interface{}{
+				"python.core.version": ">=3.${num}",
+			},
+		},
+	}
+
+	out, vars, err := interpolateConfig("/repo/.envsnap", raw, map[string]string{"pkg": "base", "base": "yes"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"venv": "/repo/.venv", "pkg": "envsnap", "num": "3", "base": "yes"}, vars)
+	assert.Equal(t, map[interface{}]interface{}{
+		"version": 1,
+		"vars": map[interface{}]interface{}{
+			"venv": "{{ .Root }}/.venv",
+			"pkg":  "envsnap",
+			"num":  3,
+		},
+		"exec": map[interface{}]interface{}{
+			"run": []interface{}{
+				"/repo/.venv/bin/python --version",
+				map[interface{}]interface{}{
+					"value": "/repo/.venv/bin/pip show envsnap",
+					"when":  map[interface{}]interface{}{"os": runtime.GOOS},
+				},
+			},
+		},
+		"checks": map[interface{}]interface{}{
+			"exec": map[interface{}]interface{}{
+				"/repo/.venv/bin/python --version": "Python 3",
+			},
+			"versions": map[interface{}]interface{}{
+				"python.core.version": ">=3.3",
+			},
+		},
+	}, out)
+}
+
+func TestInterpolateConfig_Error(t *testing.T) {
+	var tests = []struct {
+		name string
+		raw  map[interface{}]interface{}
+		err  string
+	}{
+		{
+			name: "list item",
+			raw: map[interface{}]interface{}{
+				"vars": map[interface{}]interface{}{},
+				"exec": map[interface{}]interface{}{
+					"run": []interface{}{"make --version", "${venv}/bin/python"},
+				},
+			},
+			err: "failed to interpolate config: exec.run[1]: undefined variable: venv",
+		},
+		{
+			name: "item value",
+			raw: map[interface{}]interface{}{
+				"vars": map[interface{}]interface{}{},
+				"exec": map[interface{}]interface{}{
+					"run": []interface{}{
+						map[interface{}]interface{}{"value": "${venv}/bin/python"},
+					},
+				},
+			},
+			err: "failed to interpolate config: exec.run[0].value: undefined variable: venv",
+		},
+		{
+			name: "key",
+			raw: map[interface{}]interface{}{
+				"vars": map[interface{}]interface{}{},
+				"checks": map[interface{}]interface{}{
+					"exec": map[interface{}]interface{}{"${venv}/bin/python": "3"},
+				},
+			},
+			err: "failed to interpolate config: checks.exec.${venv}/bin/python: undefined variable: venv",
+		},
+		{
+			name: "var",
+			raw: map[interface{}]interface{}{
+				"vars": map[interface{}]interface{}{
+					"venv": "${root}/.venv",
+				},
+			},
+			err: "failed to interpolate config: vars.venv: undefined variable: root",
+		},
+		{
+			name: "other var",
+			raw: map[interface{}]interface{}{
+				"vars": map[interface{}]interface{}{
+					"a":    "x",
+					"venv": "${a}/.venv",
+				},
+			},
+			err: "failed to interpolate config: vars.venv: undefined variable: a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, vars, err := interpolateConfig("/repo/.envsnap", tt.raw, nil)
+			assert.Nil(t, out)
+			assert.Nil(t, vars)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestInterpolateConfig_NoVars(t *testing.T) {
+	raw := map[interface{}]interface{}{
+		"version": 1,
+		"exec": map[interface{}]interface{}{
+			"run": []interface{}{"docker info --format {{.ServerVersion}}", "echo ${HOME}"},
+		},
+	}
+
+	out, vars, err := interpolateConfig("/repo/.envsnap", raw, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, vars)
+	assert.Equal(t, raw, out)
+}
+
+func TestRepoRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "a", "b")
+	assert.NoError(t, os.MkdirAll(sub, 0755))
+
+	// Not in a repository
+	assert.Equal(t, sub, repoRoot(sub))
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	assert.Equal(t, dir, repoRoot(sub))
+}
+
+func TestLoadConfig_Vars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "base.yml"), []byte(heredoc.Doc(`
+		version: 1
+		vars:
+		  venv: "{{ .Root }}/.venv"
+	`)), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".envsnap"), []byte(heredoc.Doc(`
+		version: 1
+		extends:
+		  - base.yml
+		vars:
+		  pkg: envsnap
+		exec:
+		  run:
+		    - ${venv}/bin/pip show ${pkg}
+	`)), 0644))
+
+	cfg, err := LoadConfig(filepath.Join(dir, ".envsnap"))
+	assert.NoError(t, err)
+
+	v1 := cfg.(*V1EnvsnapConfig)
+	assert.Equal(t, map[string]string{"venv": "{{ .Root }}/.venv", "pkg": "envsnap"}, v1.Vars)
+	assert.Equal(t, []string{dir + "/.venv/bin/pip show envsnap"}, v1.Exec.Run.Values())
+}
+
+func TestLoadConfig_VarsRemote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, heredoc.Doc(`
+			version: 1
+			vars:
+			  python: "python{{ if eq .OS \"windows\" }}.exe{{ end }}"
+			exec:
+			  run:
+			    - ${python} --version
+		`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "envsnap-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	remoteCache.Dir = filepath.Join(dir, "cache")
+	defer func() { remoteCache.Dir = "" }()
+
+	path := filepath.Join(dir, ".envsnap")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(heredoc.Docf(`
+		version: 1
+		extends:
+		  - %s/.envsnap
+		exec:
+		  run:
+		    - docker info --format {{.ServerVersion}}
+	`, server.URL)), 0644))
+
+	// The remote config's commands are trusted as they are interpolated, and
+	// the local config, which does not define vars, is not interpolated.
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	python := "python"
+	if runtime.GOOS == "windows" {
+		python = "python.exe"
+	}
+	v1 := cfg.(*V1EnvsnapConfig)
+	assert.Equal(t, []string{python + " --version", "docker info --format {{.ServerVersion}}"}, v1.Exec.Run.Values())
+	assert.Len(t, v1.Sources(), 1)
+	assert.Equal(t, []string{python + " --version"}, v1.Sources()[0].exec)
+
+	// Variables defined by remote configs can not be used by local configs.
+	assert.NoError(t, ioutil.WriteFile(path, []byte(heredoc.Docf(`
+		version: 1
+		extends:
+		  - %s/.envsnap
+		vars: {}
+		exec:
+		  run:
+		    - ${python} -m pip --version
+	`, server.URL)), 0644))
+
+	_, err = LoadConfig(path)
+	assert.EqualError(t, err, "failed to interpolate config: exec.run[0]: undefined variable: python")
+}